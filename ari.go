@@ -0,0 +1,145 @@
+// Copyright 2025 İrem Kuyucu
+// Copyright 2025 Laurynas Četyrkinas
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ARI is an Atlassian Resource Identifier of the form
+// ari:cloud:<owner>:<cloud-id>:<type>/[<workspace>/]<id>
+type ARI struct {
+	ResourceOwner string
+	CloudID       string
+	ResourceType  string
+	Workspace     string
+	ResourceID    string
+}
+
+func parseARI(s string) (ARI, error) {
+	parts := strings.SplitN(s, ":", 5)
+	if len(parts) != 5 || parts[0] != "ari" || parts[1] != "cloud" {
+		return ARI{}, fmt.Errorf("invalid ARI: %s", s)
+	}
+
+	resource := strings.Split(parts[4], "/")
+	if len(resource) < 2 || resource[0] == "" || resource[len(resource)-1] == "" {
+		return ARI{}, fmt.Errorf("invalid ARI resource: %s", s)
+	}
+
+	return ARI{
+		ResourceOwner: parts[2],
+		CloudID:       parts[3],
+		ResourceType:  resource[0],
+		Workspace:     strings.Join(resource[1:len(resource)-1], "/"),
+		ResourceID:    resource[len(resource)-1],
+	}, nil
+}
+
+// annotateARIs fills in the parsed page and space identifiers of a document
+// and derives direct Confluence URLs from them
+func annotateARIs(doc *Document, baseURL string) {
+	if page, err := parseARI(doc.ARI); err == nil {
+		doc.ResourceOwner = page.ResourceOwner
+		doc.CloudID = page.CloudID
+		doc.ResourceType = page.ResourceType
+		doc.Workspace = page.Workspace
+		doc.PageID = page.ResourceID
+	}
+
+	if space, err := parseARI(doc.ContainerARI); err == nil {
+		doc.SpaceID = space.ResourceID
+		if doc.CloudID == "" {
+			doc.CloudID = space.CloudID
+		}
+	}
+
+	if doc.PageID != "" {
+		doc.PageRESTURL = baseURL + "/wiki/rest/api/content/" + doc.PageID
+		doc.PageUIURL = baseURL + "/wiki/pages/viewpage.action?pageId=" + doc.PageID
+	}
+	if doc.SpaceID != "" {
+		doc.SpaceRESTURL = baseURL + "/wiki/api/v2/spaces/" + doc.SpaceID
+	}
+	if doc.SpaceKey != "" {
+		doc.SpaceUIURL = baseURL + "/wiki/spaces/" + doc.SpaceKey
+	}
+}
+
+// resolveSpaceKeys looks up the key of every space known only by ID, since
+// Confluence space UI URLs are built from the key, and fills in the space UI
+// URL of its documents. Spaces the session cannot read, or that no longer
+// fit in the request budget, keep no UI URL.
+func resolveSpaceKeys(client *Client, docs []Document, limits *budget) {
+	keys := make(map[string]string)
+	failed, skipped := 0, 0
+
+	for i := range docs {
+		doc := &docs[i]
+		if doc.SpaceID == "" || doc.SpaceKey != "" {
+			continue
+		}
+
+		key, ok := keys[doc.SpaceID]
+		if !ok && !limits.spend() {
+			keys[doc.SpaceID] = ""
+			skipped++
+			continue
+		}
+		if !ok {
+			var err error
+			key, err = getSpaceKey(client, doc.SpaceID)
+			if err != nil {
+				failed++
+			}
+			keys[doc.SpaceID] = key
+		}
+
+		if key != "" {
+			doc.SpaceKey = key
+			doc.SpaceUIURL = client.baseURL + "/wiki/spaces/" + key
+		}
+	}
+
+	if failed > 0 {
+		fmt.Fprintf(os.Stderr, "Warning: could not resolve the key of %d space(s), their UI URLs are left empty\n", failed)
+	}
+	if skipped > 0 {
+		fmt.Printf("Request budget exhausted, skipped the key lookup of %d space(s)\n", skipped)
+	}
+}
+
+func getSpaceKey(client *Client, spaceID string) (string, error) {
+	resp, err := client.get("/wiki/api/v2/spaces/" + spaceID)
+	if err != nil {
+		return "", fmt.Errorf("get space: %w", err)
+	}
+
+	if resp.StatusCode != 200 {
+		readBody(resp)
+		return "", fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	var space struct {
+		Key string `json:"key"`
+	}
+	if err := unmarshalJSON(resp, &space); err != nil {
+		return "", fmt.Errorf("parse space: %w", err)
+	}
+	return space.Key, nil
+}
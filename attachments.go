@@ -0,0 +1,247 @@
+// Copyright 2025 İrem Kuyucu
+// Copyright 2025 Laurynas Četyrkinas
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+type Attachment struct {
+	ID        string
+	Name      string
+	Size      int64
+	MediaType string
+	URL       string
+	Path      string
+	SHA256    string
+	Skipped   string
+}
+
+// attachmentOptions controls whether attachments are listed and which of
+// them are downloaded. An empty dir lists metadata only.
+type attachmentOptions struct {
+	enabled bool
+	dir     string
+	maxSize int64
+	types   []string
+	output  string
+}
+
+func (o attachmentOptions) allowsType(mediaType string) bool {
+	if len(o.types) == 0 {
+		return true
+	}
+	for _, t := range o.types {
+		if strings.HasPrefix(strings.ToLower(mediaType), strings.ToLower(t)) {
+			return true
+		}
+	}
+	return false
+}
+
+// fetchAttachments lists the attachments of every document and, if a
+// download directory is set, saves the allowed ones per space along with
+// their SHA-256 hashes
+func fetchAttachments(client *Client, docs []Document, opts attachmentOptions, workers int) {
+	fmt.Printf("\nListing attachments of %d document(s)\n", len(docs))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	interruptedChan := setupSignalHandler(cancel)
+
+	indexes := make(chan int, len(docs))
+	for i := range docs {
+		indexes <- i
+	}
+	close(indexes)
+
+	var mu sync.Mutex
+	listed, downloaded := 0, 0
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				if ctx.Err() != nil {
+					return
+				}
+
+				doc := &docs[i]
+				if doc.PageID == "" {
+					continue
+				}
+
+				contents, err := listAttachments(client, doc.PageID)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Warning: list attachments of '%s' failed: %v\n", doc.Title, err)
+					continue
+				}
+
+				count := 0
+				for _, content := range contents {
+					if ctx.Err() != nil {
+						break
+					}
+
+					attachment := Attachment{
+						ID:        content.ID,
+						Name:      content.Title,
+						Size:      content.Extensions.FileSize,
+						MediaType: content.Extensions.MediaType,
+						URL:       confluenceURL(client.baseURL, content.Links.Download),
+					}
+
+					if opts.dir != "" {
+						if err := downloadAttachment(client, *doc, &attachment, content.Links.Download, opts); err != nil {
+							fmt.Fprintf(os.Stderr, "Warning: download of '%s' failed: %v\n", attachment.Name, err)
+						} else if attachment.Path != "" {
+							count++
+						}
+					}
+
+					doc.Attachments = append(doc.Attachments, attachment)
+				}
+
+				mu.Lock()
+				listed += len(contents)
+				downloaded += count
+				if len(contents) > 0 {
+					fmt.Printf("%s: %d attachment(s), %d downloaded\n", doc.Title, len(contents), count)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	select {
+	case <-interruptedChan:
+		fmt.Println("\n*** Interrupted by user ***")
+	default:
+	}
+
+	fmt.Printf("Found %d attachment(s), downloaded %d\n", listed, downloaded)
+}
+
+// downloadAttachment saves an attachment under a directory named after its
+// space. Attachments over the size limit or with a filtered media type are
+// marked as skipped instead.
+func downloadAttachment(client *Client, doc Document, attachment *Attachment, link string, opts attachmentOptions) error {
+	if !opts.allowsType(attachment.MediaType) {
+		attachment.Skipped = "media type"
+		return nil
+	}
+	if opts.maxSize > 0 && attachment.Size > opts.maxSize {
+		attachment.Skipped = "size"
+		return nil
+	}
+	if link == "" {
+		return fmt.Errorf("no download link")
+	}
+
+	space := doc.ContainerName
+	if space == "" {
+		space = doc.SpaceID
+	}
+	dir := filepath.Join(opts.dir, contentFileName(space))
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("create directory: %w", err)
+	}
+
+	resp, err := client.get(confluencePath(link))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	path := filepath.Join(dir, contentFileName(doc.PageID+"_"+attachment.Name))
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("create file: %w", err)
+	}
+	defer file.Close()
+
+	body := io.Reader(resp.Body)
+	if opts.maxSize > 0 {
+		body = io.LimitReader(resp.Body, opts.maxSize+1)
+	}
+
+	hash := sha256.New()
+	written, err := io.Copy(io.MultiWriter(file, hash), body)
+	if err != nil {
+		return fmt.Errorf("write file: %w", err)
+	}
+
+	// The metadata size can be missing or wrong, so enforce the limit on
+	// the actual body too
+	if opts.maxSize > 0 && written > opts.maxSize {
+		file.Close()
+		os.Remove(path)
+		attachment.Skipped = "size"
+		return nil
+	}
+
+	attachment.Path = path
+	attachment.Size = written
+	attachment.SHA256 = hex.EncodeToString(hash.Sum(nil))
+	return nil
+}
+
+func writeAttachmentsToCSV(docs []Document, outputPath string) error {
+	file, err := os.Create(outputPath)
+	if err != nil {
+		return fmt.Errorf("create CSV file: %w", err)
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	defer writer.Flush()
+
+	if err := writer.Write([]string{"Document ARI", "Document", "Container", "Name", "Size", "Media Type", "URL", "Path", "SHA-256", "Skipped"}); err != nil {
+		return fmt.Errorf("write CSV header: %w", err)
+	}
+
+	count := 0
+	for _, doc := range docs {
+		for _, a := range doc.Attachments {
+			row := []string{doc.ARI, doc.Title, doc.ContainerName, a.Name, strconv.FormatInt(a.Size, 10), a.MediaType, a.URL, a.Path, a.SHA256, a.Skipped}
+			if err := writer.Write(row); err != nil {
+				return fmt.Errorf("write CSV row: %w", err)
+			}
+			count++
+		}
+	}
+
+	fmt.Printf("\nWrote %d attachments to %s\n", count, outputPath)
+	return nil
+}
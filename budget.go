@@ -0,0 +1,119 @@
+// Copyright 2025 İrem Kuyucu
+// Copyright 2025 Laurynas Četyrkinas
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// budget holds the hard limits of a crawl. Zero values mean unlimited.
+// Requests and depth are checked by the results processor when queueing
+// tasks, so only one goroutine touches the counters at a time.
+type budget struct {
+	maxRequests int
+	maxDepth    int
+	maxDuration time.Duration
+
+	requests    int
+	requestsHit bool
+	depthHit    bool
+}
+
+func newBudget(maxRequests, maxDepth int, maxDuration time.Duration) *budget {
+	return &budget{
+		maxRequests: maxRequests,
+		maxDepth:    maxDepth,
+		maxDuration: maxDuration,
+	}
+}
+
+// withDeadline derives a context that is cancelled once the wall-clock
+// budget runs out
+func (b *budget) withDeadline(parent context.Context) (context.Context, context.CancelFunc) {
+	if b.maxDuration > 0 {
+		return context.WithTimeout(parent, b.maxDuration)
+	}
+	return context.WithCancel(parent)
+}
+
+// spend reserves one request, returning false if the request budget is used up
+func (b *budget) spend() bool {
+	if b.maxRequests > 0 && b.requests >= b.maxRequests {
+		b.requestsHit = true
+		return false
+	}
+	b.requests++
+	return true
+}
+
+// spendN reserves n requests at once, returning false without spending
+// anything if they do not all fit in the budget
+func (b *budget) spendN(n int) bool {
+	if b.maxRequests > 0 && b.requests+n > b.maxRequests {
+		b.requestsHit = true
+		return false
+	}
+	b.requests += n
+	return true
+}
+
+// spendUpTo reserves as many of n requests as the budget allows and
+// returns how many it reserved
+func (b *budget) spendUpTo(n int) int {
+	spent := 0
+	for spent < n && b.spend() {
+		spent++
+	}
+	return spent
+}
+
+// exhausted reports whether no further requests may be queued. Callers
+// skip work when it does, so the budget is recorded as having stopped the run.
+func (b *budget) exhausted() bool {
+	if b.maxRequests > 0 && b.requests >= b.maxRequests {
+		b.requestsHit = true
+		return true
+	}
+	return false
+}
+
+// allowDepth reports whether a task at the given depth may be queued
+func (b *budget) allowDepth(depth int) bool {
+	if b.maxDepth > 0 && depth > b.maxDepth {
+		b.depthHit = true
+		return false
+	}
+	return true
+}
+
+// stopReason describes which budgets ended the run, or "" if none did
+func (b *budget) stopReason(ctx context.Context) string {
+	var reasons []string
+	if b.requestsHit {
+		reasons = append(reasons, fmt.Sprintf("request budget of %d exhausted", b.maxRequests))
+	}
+	if b.depthHit {
+		reasons = append(reasons, fmt.Sprintf("depth limit of %d reached", b.maxDepth))
+	}
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		reasons = append(reasons, fmt.Sprintf("time budget of %s exhausted", b.maxDuration))
+	}
+	return strings.Join(reasons, ", ")
+}
@@ -0,0 +1,203 @@
+// Copyright 2025 İrem Kuyucu
+// Copyright 2025 Laurynas Četyrkinas
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type presenceCheck struct {
+	Entry       string
+	Found       bool
+	AccountID   string
+	DisplayName string
+	DeskIDs     []string
+}
+
+type presenceTask struct {
+	index  int
+	deskID string
+}
+
+type presenceResult struct {
+	index  int
+	deskID string
+	user   *User
+	err    error
+}
+
+func checkUserList(baseURL, cookie string, entries []string, deskID, outputPath string, workers, timeout int) error {
+	client := newClient(baseURL, cookie, time.Duration(timeout)*time.Second)
+
+	var desks []ServiceDesk
+	if deskID != "" {
+		desks = []ServiceDesk{{ID: deskID}}
+	} else {
+		var err error
+		desks, err = getServiceDesks(client)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("\nFound %d service desk(s)\n", len(desks))
+	}
+
+	fmt.Printf("Checking %d entries against %d desk(s)\n\n", len(entries), len(desks))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	interruptedChan := setupSignalHandler(cancel)
+
+	checks := make([]presenceCheck, len(entries))
+	for i, entry := range entries {
+		checks[i].Entry = entry
+	}
+
+	taskQueue := make(chan presenceTask, len(entries)*len(desks))
+	results := make(chan presenceResult, workers*2)
+
+	for i := range entries {
+		for _, desk := range desks {
+			taskQueue <- presenceTask{index: i, deskID: desk.ID}
+		}
+	}
+	close(taskQueue)
+
+	// Worker pool
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for task := range taskQueue {
+				select {
+				case <-ctx.Done():
+					return
+				default:
+				}
+
+				users, err := searchUsers(client, task.deskID, entries[task.index])
+				result := presenceResult{index: task.index, deskID: task.deskID, err: err}
+				if err == nil {
+					result.user = matchUser(users, entries[task.index])
+				}
+
+				select {
+				case results <- result:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	for result := range results {
+		if result.err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: check for '%s' on desk %s failed: %v\n", entries[result.index], result.deskID, result.err)
+			continue
+		}
+		if result.user == nil {
+			continue
+		}
+
+		check := &checks[result.index]
+		check.Found = true
+		check.AccountID = result.user.AccountID
+		check.DisplayName = result.user.DisplayName
+		check.DeskIDs = append(check.DeskIDs, result.deskID)
+	}
+
+	select {
+	case <-interruptedChan:
+		fmt.Println("\n*** Interrupted by user ***")
+	default:
+	}
+
+	found := 0
+	for _, check := range checks {
+		if check.Found {
+			found++
+		}
+	}
+	fmt.Printf("Found %d/%d entries\n", found, len(checks))
+
+	if outputPath != "" {
+		return writePresenceToCSV(checks, outputPath)
+	}
+
+	printPresence(checks)
+	return nil
+}
+
+// matchUser returns the user whose email or display name equals the entry
+func matchUser(users []User, entry string) *User {
+	for i := range users {
+		if strings.EqualFold(users[i].EmailAddress, entry) || strings.EqualFold(users[i].DisplayName, entry) {
+			return &users[i]
+		}
+	}
+	return nil
+}
+
+func writePresenceToCSV(checks []presenceCheck, outputPath string) error {
+	file, err := os.Create(outputPath)
+	if err != nil {
+		return fmt.Errorf("create CSV file: %w", err)
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	defer writer.Flush()
+
+	if err := writer.Write([]string{"Entry", "Found", "AccountID", "DisplayName", "Desks"}); err != nil {
+		return fmt.Errorf("write CSV header: %w", err)
+	}
+
+	for _, check := range checks {
+		row := []string{check.Entry, strconv.FormatBool(check.Found), check.AccountID, check.DisplayName, strings.Join(check.DeskIDs, ";")}
+		if err := writer.Write(row); err != nil {
+			return fmt.Errorf("write CSV row: %w", err)
+		}
+	}
+
+	fmt.Printf("\nWrote %d entries to %s\n", len(checks), outputPath)
+	return nil
+}
+
+func printPresence(checks []presenceCheck) {
+	fmt.Println("\nPresence Check:")
+	fmt.Println(strings.Repeat("-", 100))
+
+	for _, check := range checks {
+		if !check.Found {
+			fmt.Printf("[✗] %s\n", check.Entry)
+			continue
+		}
+		fmt.Printf("[✓] %s -> %s (%s) [Desks: %s]\n", check.Entry, check.AccountID, check.DisplayName, strings.Join(check.DeskIDs, ", "))
+	}
+}
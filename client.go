@@ -18,27 +18,45 @@ package main
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"time"
 )
 
+// errAccessDenied marks a REST request the server refused with 401 or 403
+var errAccessDenied = errors.New("access denied")
+
 type Client struct {
 	baseURL    string
 	cookie     string
+	cookieName string
 	httpClient *http.Client
 	maxRetries int
 }
 
+func sessionCookieName(tenant bool) string {
+	if tenant {
+		return "tenant.session.token"
+	}
+	return "customer.account.session.token"
+}
+
 func newClient(baseURL, cookie string, timeout time.Duration) *Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if clientConfig.proxyURL != nil {
+		transport.Proxy = http.ProxyURL(clientConfig.proxyURL)
+	}
+
 	return &Client{
 		baseURL: baseURL,
 		cookie:  cookie,
 		httpClient: &http.Client{
-			Timeout: timeout,
+			Timeout:   timeout,
+			Transport: transport,
 		},
-		maxRetries: 3,
+		maxRetries: clientConfig.retries,
 	}
 }
 
@@ -48,14 +66,20 @@ func (c *Client) post(path string, body interface{}) (*http.Response, error) {
 		return nil, fmt.Errorf("marshal request: %w", err)
 	}
 
-	return c.doWithRetry("POST", path, jsonData)
+	return c.doWithRetry("POST", path, jsonData, nil)
 }
 
 func (c *Client) get(path string) (*http.Response, error) {
-	return c.doWithRetry("GET", path, nil)
+	return c.doWithRetry("GET", path, nil, nil)
+}
+
+// getWithHeaders is get with headers only this endpoint needs. A --header
+// of the same name takes precedence.
+func (c *Client) getWithHeaders(path string, header http.Header) (*http.Response, error) {
+	return c.doWithRetry("GET", path, nil, header)
 }
 
-func (c *Client) doWithRetry(method, path string, body []byte) (*http.Response, error) {
+func (c *Client) doWithRetry(method, path string, body []byte, header http.Header) (*http.Response, error) {
 	var lastErr error
 
 	for attempt := 0; attempt <= c.maxRetries; attempt++ {
@@ -82,10 +106,29 @@ func (c *Client) doWithRetry(method, path string, body []byte) (*http.Response,
 			req.Header.Set("Content-Type", "application/json")
 		}
 
+		for name, values := range header {
+			if clientConfig.header.Get(name) != "" {
+				continue
+			}
+			for _, value := range values {
+				req.Header.Add(name, value)
+			}
+		}
+
+		if clientConfig.userAgent != "" {
+			req.Header.Set("User-Agent", clientConfig.userAgent)
+		}
+		for name, values := range clientConfig.header {
+			for _, value := range values {
+				req.Header.Add(name, value)
+			}
+		}
+
 		if c.cookie != "" {
-			cookieName := "customer.account.session.token" 
-			if *tenantSession {
-				cookieName = "tenant.session.token"
+			// Clients for a second auth context name their own cookie
+			cookieName := c.cookieName
+			if cookieName == "" {
+				cookieName = sessionCookieName(*tenantSession)
 			}
 			req.AddCookie(&http.Cookie{
 				Name:  cookieName,
@@ -93,6 +136,8 @@ func (c *Client) doWithRetry(method, path string, body []byte) (*http.Response,
 			})
 		}
 
+		clientConfig.limiter.wait()
+
 		resp, err := c.httpClient.Do(req)
 		if err != nil {
 			lastErr = err
@@ -0,0 +1,170 @@
+// Copyright 2025 İrem Kuyucu
+// Copyright 2025 Laurynas Četyrkinas
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// comparisonOptions selects a second auth context to diff the permissions
+// of the session against. An empty cookie is an anonymous visitor.
+type comparisonOptions struct {
+	enabled bool
+	cookie  string
+	tenant  bool
+	output  string
+}
+
+func newComparisonOptions(value string, tenant bool, output string) comparisonOptions {
+	options := comparisonOptions{enabled: value != "", tenant: tenant, output: output}
+	if !strings.EqualFold(value, "anonymous") {
+		options.cookie = value
+	}
+	return options
+}
+
+func (o comparisonOptions) label() string {
+	if o.cookie == "" {
+		return "Anonymous"
+	}
+	return "Compare"
+}
+
+// PermissionChange is a permission held in only one of the two contexts
+type PermissionChange struct {
+	Context  string
+	Key      string
+	Severity severity
+	Session  bool
+	Compare  bool
+}
+
+// comparePermissions fetches the same permissions, globally and per project,
+// with the second auth context and reports where the two differ
+func comparePermissions(baseURL string, timeout int, permKeys, projectKeys []string, session permissionMatrix, compare comparisonOptions) error {
+	client := newClient(baseURL, compare.cookie, time.Duration(timeout)*time.Second)
+	client.cookieName = sessionCookieName(compare.tenant)
+
+	global, err := getMyPermissions(client, permKeys, "")
+	if err != nil {
+		return fmt.Errorf("compare context: %w", err)
+	}
+
+	other := permissionMatrix{
+		contexts: []string{"Global"},
+		grants:   map[string]map[string]MyPermission{"Global": global},
+	}
+	checkProjectPermissions(client, permKeys, projectKeys, &other)
+
+	changes := diffPermissions(session, other, permKeys)
+	if compare.output != "" {
+		return writeChangesToCSV(changes, compare.label(), compare.output)
+	}
+
+	printChanges(changes, compare.label())
+	return nil
+}
+
+// diffPermissions lists the permissions that differ per context, dangerous
+// ones first. Contexts missing from either matrix are skipped.
+func diffPermissions(session, other permissionMatrix, permKeys []string) []PermissionChange {
+	keys := append([]string(nil), permKeys...)
+	sortPermissionKeys(keys, func(key string) bool {
+		return session.granted(key) || other.granted(key)
+	}, session.permission)
+
+	var changes []PermissionChange
+	for _, context := range session.contexts {
+		if other.grants[context] == nil {
+			continue
+		}
+		for _, key := range keys {
+			has, otherHas := session.has(context, key), other.has(context, key)
+			if has == otherHas {
+				continue
+			}
+			changes = append(changes, PermissionChange{
+				Context:  context,
+				Key:      key,
+				Severity: riskOf(session.permission(key)).severity,
+				Session:  has,
+				Compare:  otherHas,
+			})
+		}
+	}
+
+	return changes
+}
+
+func writeChangesToCSV(changes []PermissionChange, label, outputPath string) error {
+	file, err := os.Create(outputPath)
+	if err != nil {
+		return fmt.Errorf("create CSV file: %w", err)
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	defer writer.Flush()
+
+	if err := writer.Write([]string{"Context", "Permission", "Risk", "Session", label}); err != nil {
+		return fmt.Errorf("write CSV header: %w", err)
+	}
+
+	for _, c := range changes {
+		row := []string{c.Context, c.Key, c.Severity.String(), strconv.FormatBool(c.Session), strconv.FormatBool(c.Compare)}
+		if err := writer.Write(row); err != nil {
+			return fmt.Errorf("write CSV row: %w", err)
+		}
+	}
+
+	fmt.Printf("\nWrote %d permission differences to %s\n", len(changes), outputPath)
+	return nil
+}
+
+func printChanges(changes []PermissionChange, label string) {
+	gained := 0
+	for _, c := range changes {
+		if c.Session {
+			gained++
+		}
+	}
+
+	fmt.Printf("\nPermission diff (Session vs %s):\n", label)
+	fmt.Println(strings.Repeat("-", 80))
+	fmt.Printf("    %-10s %-8s %-35s %-8s %s\n", "Context", "Risk", "Permission", "Session", label)
+
+	for _, c := range changes {
+		marker := "-"
+		if c.Session {
+			marker = "+"
+		}
+		fmt.Printf("[%s] %-10s %-8s %-35s %-8s %s\n", marker, c.Context, c.Severity, c.Key, mark(c.Session), mark(c.Compare))
+	}
+
+	fmt.Printf("\nSession holds %d permission(s) %s lacks and lacks %d it holds\n", gained, label, len(changes)-gained)
+}
+
+func mark(granted bool) string {
+	if granted {
+		return "✓"
+	}
+	return "✗"
+}
@@ -0,0 +1,163 @@
+// Copyright 2025 İrem Kuyucu
+// Copyright 2025 Laurynas Četyrkinas
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+type ConfluencePage struct {
+	ID      string `json:"id"`
+	Title   string `json:"title"`
+	Version struct {
+		When string `json:"when"`
+	} `json:"version"`
+	Body struct {
+		View struct {
+			Value string `json:"value"`
+		} `json:"view"`
+	} `json:"body"`
+}
+
+var (
+	blockTagPattern = regexp.MustCompile(`(?i)<(br|/p|/div|/h[1-6]|/li|/tr|/pre|/blockquote)\b[^>]*>`)
+	anyTagPattern   = regexp.MustCompile(`<[^>]*>`)
+	blankRunPattern = regexp.MustCompile(`\n{3,}`)
+	unsafePathChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+)
+
+// fetchContents downloads the body of every document through the Confluence
+// REST API, writes it to dir as HTML and plain text, and fills in the word
+// count and last-modified metadata in place
+func fetchContents(client *Client, docs []Document, dir string, workers int) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("create content directory: %w", err)
+	}
+
+	fmt.Printf("\nFetching content of %d document(s) into %s\n", len(docs), dir)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	interruptedChan := setupSignalHandler(cancel)
+
+	indexes := make(chan int, len(docs))
+	for i := range docs {
+		indexes <- i
+	}
+	close(indexes)
+
+	var mu sync.Mutex
+	fetched := 0
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				if ctx.Err() != nil {
+					return
+				}
+
+				doc := &docs[i]
+				if err := fetchContent(client, doc, dir); err != nil {
+					fmt.Fprintf(os.Stderr, "Warning: fetch content of '%s' failed: %v\n", doc.Title, err)
+					continue
+				}
+
+				mu.Lock()
+				fetched++
+				fmt.Printf("[%d/%d] %s (%d words)\n", fetched, len(docs), doc.Title, doc.WordCount)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	select {
+	case <-interruptedChan:
+		fmt.Println("\n*** Interrupted by user ***")
+	default:
+	}
+
+	fmt.Printf("Fetched content of %d/%d document(s)\n", fetched, len(docs))
+	return nil
+}
+
+func fetchContent(client *Client, doc *Document, dir string) error {
+	if doc.PageID == "" {
+		return fmt.Errorf("no page ID in ARI %s", doc.ARI)
+	}
+
+	resp, err := client.get("/wiki/rest/api/content/" + doc.PageID + "?expand=body.view,version")
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != 200 {
+		readBody(resp)
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	var page ConfluencePage
+	if err := unmarshalJSON(resp, &page); err != nil {
+		return err
+	}
+
+	body := page.Body.View.Value
+	text := htmlToText(body)
+
+	base := filepath.Join(dir, contentFileName(doc.ARI))
+	if err := os.WriteFile(base+".html", []byte(body), 0o644); err != nil {
+		return fmt.Errorf("write HTML: %w", err)
+	}
+	if err := os.WriteFile(base+".txt", []byte(text), 0o644); err != nil {
+		return fmt.Errorf("write text: %w", err)
+	}
+
+	doc.ContentPath = base + ".txt"
+	doc.WordCount = len(strings.Fields(text))
+	doc.LastModified = page.Version.When
+	return nil
+}
+
+// contentFileName turns an ARI into a safe file name
+func contentFileName(ari string) string {
+	return strings.Trim(unsafePathChars.ReplaceAllString(ari, "_"), "_")
+}
+
+// htmlToText strips tags from Confluence storage HTML, keeping line breaks
+// at block boundaries
+func htmlToText(body string) string {
+	text := blockTagPattern.ReplaceAllString(body, "\n")
+	text = anyTagPattern.ReplaceAllString(text, "")
+	text = html.UnescapeString(text)
+
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+
+	return strings.TrimSpace(blankRunPattern.ReplaceAllString(strings.Join(lines, "\n"), "\n\n")) + "\n"
+}
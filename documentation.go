@@ -18,8 +18,11 @@ package main
 import (
 	"context"
 	"encoding/csv"
+	"errors"
 	"fmt"
 	"os"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -36,12 +39,13 @@ type DocsGraphQLResponse struct {
 				ContainerName string `json:"containerName"`
 				Title         string `json:"title"`
 			} `json:"results"`
+			PageInfo struct {
+				EndCursor   string `json:"endCursor"`
+				HasNextPage bool   `json:"hasNextPage"`
+			} `json:"pageInfo"`
 		} `json:"helpObjectStore_searchArticles"`
 	} `json:"data"`
-	Errors []struct {
-		Message string        `json:"message"`
-		Path    []interface{} `json:"path"`
-	} `json:"errors"`
+	Errors []GraphQLError `json:"errors"`
 }
 
 type Document struct {
@@ -50,6 +54,26 @@ type Document struct {
 	AbsoluteURL   string
 	ContainerARI  string
 	ContainerName string
+	ResourceOwner string
+	CloudID       string
+	ResourceType  string
+	Workspace     string
+	PageID        string
+	SpaceID       string
+	SpaceKey      string
+	PageRESTURL   string
+	PageUIURL     string
+	SpaceRESTURL  string
+	SpaceUIURL    string
+	ContentPath   string
+	WordCount     int
+	LastModified  string
+	Keywords      []string
+	HelpCenters   []string
+	Attachments   []Attachment
+	Exposure      string
+	FirstSeen     time.Time
+	LastSeen      time.Time
 }
 
 type TenantInfo struct {
@@ -57,11 +81,16 @@ type TenantInfo struct {
 }
 
 type searchTask struct {
-	query string
-	depth int
+	helpCenter HelpCenter
+	keyword    string
+	query      string
+	depth      int
+	found      []string // ARIs the session saw, recorded once the task completed
 }
 
 type searchResult struct {
+	helpCenter HelpCenter
+	keyword    string
 	query      string
 	depth      int
 	totalCount int
@@ -69,27 +98,62 @@ type searchResult struct {
 	err        error
 }
 
-func enumerateDocs(baseURL, cookie, alphabet1, alphabet2, output string, workers, timeout int) error {
+func enumerateDocs(baseURL, cookie, alphabet1, alphabet2, output string, workers, timeout int, limits *budget, contentDir string, rules []scanRule, findingsOutput string, keywords []string, filter spaceFilter, groupSpaces bool, spacesOutput string, crawl bool, hierarchyOutput string, attachments attachmentOptions, compareAnonymous bool, compareSample int, pageSize int, helpCenterARIs []string, helpCenterArg string, backendName string) error {
 	client := newClient(baseURL, cookie, time.Duration(timeout)*time.Second)
 
-	cloudID, err := getCloudID(client)
+	backend, err := selectDocsBackend(client, backendName, pageSize)
 	if err != nil {
-		return fmt.Errorf("failed to get cloud ID: %w", err)
+		return err
 	}
 
-	fmt.Println("Fetching all documents for cloud ID: " + cloudID)
+	if backend.cloudID != "" {
+		fmt.Println("Fetching all documents for cloud ID: " + backend.cloudID)
+	}
+	fmt.Println("Backend: " + backend.name)
 	fmt.Println("Alphabet (layer 1): " + alphabet1)
 	fmt.Println("Alphabet (layer 2+): " + alphabet2)
 	fmt.Printf("Concurrent workers: %d\n", workers)
 	fmt.Printf("Request timeout: %ds\n", timeout)
 
-	ctx, cancel := context.WithCancel(context.Background())
+	helpCenters := backend.helpCenters(client, helpCenterARIs, helpCenterArg)
+
+	ctx, cancel := limits.withDeadline(context.Background())
 	defer cancel()
+	deadline, _ := ctx.Deadline()
 
 	interruptedChan := setupSignalHandler(cancel)
 
 	docMap := make(map[string]Document)
-	taskQueue := make(chan searchTask, 5000)
+	docKeywords := make(map[string]map[string]bool)
+	var issuedTasks []searchTask
+
+	// Keywords replace the empty initial query; saturated keywords are
+	// expanded with suffixes like any other prefix
+	queries := []string{""}
+	depth := 0
+	if len(keywords) > 0 {
+		queries = keywords
+	} else if backend.name == backendServiceDesk {
+		// The knowledge base API needs a query term, so its crawl starts
+		// one level down with the layer 1 alphabet
+		queries = strings.Split(alphabet1, "")
+		depth = 1
+	}
+
+	initialTasks := make([]searchTask, 0, len(queries)*len(helpCenters))
+	for _, helpCenter := range helpCenters {
+		for _, query := range queries {
+			if !limits.spend() {
+				break
+			}
+			keyword := ""
+			if len(keywords) > 0 {
+				keyword = query
+			}
+			initialTasks = append(initialTasks, searchTask{helpCenter: helpCenter, keyword: keyword, query: query, depth: depth})
+		}
+	}
+	taskQueue := make(chan searchTask, 5000+len(initialTasks))
 	results := make(chan searchResult, workers*2)
 
 	// Worker pool
@@ -107,10 +171,12 @@ func enumerateDocs(baseURL, cookie, alphabet1, alphabet2, output string, workers
 						return
 					}
 
-					totalCount, docs, err := searchDocuments(client, cloudID, task.query, 2147483647)
+					totalCount, docs, err := backend.search(client, task.helpCenter, task.query)
 
 					select {
 					case results <- searchResult{
+						helpCenter: task.helpCenter,
+						keyword:    task.keyword,
 						query:      task.query,
 						depth:      task.depth,
 						totalCount: totalCount,
@@ -130,16 +196,36 @@ func enumerateDocs(baseURL, cookie, alphabet1, alphabet2, output string, workers
 	processorWg.Add(1)
 	go func() {
 		defer processorWg.Done()
-		pendingTasks := 1
+		pendingTasks := len(initialTasks)
 		searchCount := 0
 		expectedTotal := 0
 
+		// The expected total is only complete once the root query of every
+		// help center has reported; a failed root query leaves it unknown
+		rootsPending := 0
+		for _, task := range initialTasks {
+			if task.query == "" && task.depth == 0 {
+				rootsPending++
+			}
+		}
+		totalKnown := true
+
 		for result := range results {
 			pendingTasks--
 			searchCount++
 
+			if result.query == "" && result.depth == 0 {
+				rootsPending--
+				if result.err != nil {
+					totalKnown = false
+				}
+			}
+
 			if result.err != nil {
-				fmt.Fprintf(os.Stderr, "Warning: search for '%s' failed: %v\n", result.query, result.err)
+				// Schema mismatches are reported once by searchDocuments
+				if !errors.Is(result.err, errGraphQLSchema) {
+					fmt.Fprintf(os.Stderr, "Warning: search for '%s' failed: %v\n", result.query, result.err)
+				}
 				if pendingTasks == 0 {
 					cancel()
 					return
@@ -147,16 +233,36 @@ func enumerateDocs(baseURL, cookie, alphabet1, alphabet2, output string, workers
 				continue
 			}
 
-			if searchCount == 1 {
-				expectedTotal = result.totalCount
+			// Only the empty query reports the size of a whole knowledge base
+			if result.query == "" && result.depth == 0 {
+				expectedTotal += result.totalCount
+			}
+
+			issued := searchTask{helpCenter: result.helpCenter, query: result.query}
+			for _, doc := range result.docs {
+				issued.found = append(issued.found, doc.ARI)
 			}
+			issuedTasks = append(issuedTasks, issued)
 
 			newDocs := 0
+			now := time.Now()
 			for _, doc := range result.docs {
-				if _, exists := docMap[doc.ARI]; !exists {
+				if existing, exists := docMap[doc.ARI]; exists {
+					existing.LastSeen = now
+					existing.HelpCenters = appendUnique(existing.HelpCenters, result.helpCenter.Name)
+					docMap[doc.ARI] = existing
+				} else {
+					doc.FirstSeen, doc.LastSeen = now, now
+					doc.HelpCenters = []string{result.helpCenter.Name}
 					docMap[doc.ARI] = doc
 					newDocs++
 				}
+				if result.keyword != "" && !docKeywords[doc.ARI][result.keyword] {
+					if docKeywords[doc.ARI] == nil {
+						docKeywords[doc.ARI] = make(map[string]bool)
+					}
+					docKeywords[doc.ARI][result.keyword] = true
+				}
 			}
 			uniqueCount := len(docMap)
 
@@ -174,7 +280,7 @@ func enumerateDocs(baseURL, cookie, alphabet1, alphabet2, output string, workers
 			fmt.Printf("[%d] %s Query: %s | Results: %4d/%d | New: %3d | Unique: %d/%d | Pending: %d\n",
 				searchCount, status, queryDisplay, len(result.docs), result.totalCount, newDocs, uniqueCount, expectedTotal, pendingTasks)
 
-			if truncated {
+			if truncated && limits.allowDepth(result.depth+1) {
 				select {
 				case <-ctx.Done():
 					return
@@ -185,7 +291,11 @@ func enumerateDocs(baseURL, cookie, alphabet1, alphabet2, output string, workers
 					}
 
 					for _, char := range alphabet {
-						newTask := searchTask{query: result.query + string(char), depth: result.depth + 1}
+						if !limits.spend() {
+							break
+						}
+
+						newTask := searchTask{helpCenter: result.helpCenter, keyword: result.keyword, query: result.query + string(char), depth: result.depth + 1}
 						pendingTasks++
 
 						select {
@@ -197,14 +307,16 @@ func enumerateDocs(baseURL, cookie, alphabet1, alphabet2, output string, workers
 				}
 			}
 
-			if pendingTasks == 0 || (uniqueCount >= expectedTotal && expectedTotal > 0) {
+			if pendingTasks == 0 || (totalKnown && rootsPending == 0 && expectedTotal > 0 && uniqueCount >= expectedTotal) {
 				cancel()
 				return
 			}
 		}
 	}()
 
-	taskQueue <- searchTask{query: "", depth: 0}
+	for _, task := range initialTasks {
+		taskQueue <- task
+	}
 
 	go func() {
 		<-ctx.Done()
@@ -221,6 +333,10 @@ func enumerateDocs(baseURL, cookie, alphabet1, alphabet2, output string, workers
 	default:
 	}
 
+	if reason := limits.stopReason(ctx); reason != "" {
+		fmt.Printf("\n*** Stopped early: %s ***\n", reason)
+	}
+
 	fmt.Printf("\nTotal documents found: %d\n", len(docMap))
 
 	if len(docMap) == 0 {
@@ -230,20 +346,172 @@ func enumerateDocs(baseURL, cookie, alphabet1, alphabet2, output string, workers
 
 	// Convert map to slice
 	finalDocs := make([]Document, 0, len(docMap))
-	for _, doc := range docMap {
+	for ari, doc := range docMap {
+		if !filter.allows(doc) {
+			continue
+		}
+		for keyword := range docKeywords[ari] {
+			doc.Keywords = append(doc.Keywords, keyword)
+		}
+		sort.Strings(doc.Keywords)
 		finalDocs = append(finalDocs, doc)
 	}
 
+	if len(finalDocs) < len(docMap) {
+		fmt.Printf("Kept %d document(s) after space filters\n", len(finalDocs))
+	}
+
+	// Rank by the number of sensitive keywords each document matched
+	if len(keywords) > 0 {
+		sort.SliceStable(finalDocs, func(i, j int) bool {
+			if len(finalDocs[i].Keywords) != len(finalDocs[j].Keywords) {
+				return len(finalDocs[i].Keywords) > len(finalDocs[j].Keywords)
+			}
+			return finalDocs[i].Title < finalDocs[j].Title
+		})
+	}
+
+	resolveSpaceKeys(client, finalDocs, limits)
+
+	if compareAnonymous {
+		compareDocsAnonymous(baseURL, time.Duration(timeout)*time.Second, backend, issuedTasks, compareSample, finalDocs, workers, limits)
+	}
+
+	if contentDir != "" {
+		if err := fetchContents(client, finalDocs, contentDir, workers); err != nil {
+			return err
+		}
+	}
+
+	if attachments.enabled {
+		fetchAttachments(client, finalDocs, attachments, workers)
+	}
+
+	var hidden []HiddenContent
+	if crawl {
+		hidden = crawlHierarchy(client, finalDocs, workers, limits, deadline)
+	}
+
+	spaces := groupBySpace(finalDocs)
+
 	if output != "" {
-		return writeDocsToCSV(finalDocs, output)
+		if err := writeDocsToCSV(finalDocs, output); err != nil {
+			return err
+		}
+	} else if groupSpaces {
+		printSpaces(spaces)
+	} else {
+		printDocuments(finalDocs)
 	}
 
-	printDocuments(finalDocs)
+	if spacesOutput != "" {
+		if err := writeSpacesToCSV(spaces, spacesOutput); err != nil {
+			return err
+		}
+	}
+
+	if attachments.enabled && attachments.output != "" {
+		if err := writeAttachmentsToCSV(finalDocs, attachments.output); err != nil {
+			return err
+		}
+	}
+
+	if crawl {
+		if hierarchyOutput != "" {
+			if err := writeHiddenToCSV(hidden, hierarchyOutput); err != nil {
+				return err
+			}
+		} else {
+			printHidden(hidden)
+		}
+	}
+
+	if rules == nil {
+		return nil
+	}
+
+	findings := scanDocuments(finalDocs, rules)
+	if findingsOutput != "" {
+		return writeFindingsToCSV(findings, findingsOutput)
+	}
+
+	printFindings(findings)
 	return nil
 }
 
-func searchDocuments(client *Client, cloudID, queryTerm string, limit int) (int, []Document, error) {
-	query := `query MyQuery($cloudId:ID!,$queryTerm:String,$limit:Int!){helpObjectStore_searchArticles(cloudId:$cloudId,queryTerm:$queryTerm,limit:$limit){...on HelpObjectStoreArticleSearchResults{totalCount results{absoluteUrl ari containerAri containerName title}}}}`
+// searchDocuments returns every article matching the query term, paging
+// through the results when the schema allows it and shrinking the limit
+// when the gateway rejects it
+func searchDocuments(client *Client, cloudID, helpCenterARI, queryTerm string, paging *docsPaging) (int, []Document, error) {
+	var docs []Document
+	totalCount := 0
+	offset := 0
+	cursor := ""
+	rateLimited := 0
+
+	for {
+		limit := paging.currentLimit()
+		page, err := searchArticlesPage(client, cloudID, helpCenterARI, queryTerm, limit, paging, offset, cursor)
+
+		var schemaErr *SchemaError
+		switch {
+		case errors.Is(err, errLimitRejected) && paging.shrink(limit):
+			continue
+		case errors.Is(err, errGraphQLRateLimit) && rateLimited < client.maxRetries:
+			// Exponential backoff: 1s, 2s, 4s
+			time.Sleep(time.Duration(1<<uint(rateLimited)) * time.Second)
+			rateLimited++
+			continue
+		case errors.As(err, &schemaErr):
+			warnSchemaOnce(schemaErr)
+			return 0, nil, err
+		case errors.Is(err, errGraphQLPartial):
+			// Keep whatever data came back alongside the errors
+			fmt.Fprintf(os.Stderr, "Warning: search for '%s' returned partial results: %v\n", queryTerm, err)
+		case err != nil:
+			return 0, nil, err
+		}
+
+		results := page.Data.HelpObjectStoreSearchArticles
+		totalCount = results.TotalCount
+		// Partial pages are short because of the errors, not a server cap
+		if err == nil {
+			paging.observe(limit, len(results.Results), totalCount)
+		}
+
+		for _, result := range results.Results {
+			doc := Document{
+				ARI:           result.ARI,
+				Title:         result.Title,
+				AbsoluteURL:   result.AbsoluteURL,
+				ContainerARI:  result.ContainerARI,
+				ContainerName: result.ContainerName,
+			}
+			annotateARIs(&doc, client.baseURL)
+			docs = append(docs, doc)
+		}
+
+		if !paging.paginated() || len(results.Results) == 0 || len(docs) >= totalCount {
+			break
+		}
+
+		if paging.cursorArg != "" {
+			if !results.PageInfo.HasNextPage || results.PageInfo.EndCursor == "" {
+				break
+			}
+			cursor = results.PageInfo.EndCursor
+		} else {
+			offset += len(results.Results)
+		}
+	}
+
+	return totalCount, docs, nil
+}
+
+func searchArticlesPage(client *Client, cloudID, helpCenterARI, queryTerm string, limit int, paging *docsPaging, offset int, cursor string) (*DocsGraphQLResponse, error) {
+	params := "$cloudId:ID!,$queryTerm:String,$limit:Int!"
+	args := "cloudId:$cloudId,queryTerm:$queryTerm,limit:$limit"
+	fields := "totalCount results{absoluteUrl ari containerAri containerName title}"
 
 	variables := map[string]interface{}{
 		"cloudId": cloudID,
@@ -254,6 +522,28 @@ func searchDocuments(client *Client, cloudID, queryTerm string, limit int) (int,
 		variables["queryTerm"] = queryTerm
 	}
 
+	if helpCenterARI != "" && paging.helpCenterArg != "" {
+		params += ",$helpCenter:ID"
+		args += "," + paging.helpCenterArg + ":$helpCenter"
+		variables["helpCenter"] = helpCenterARI
+	}
+
+	switch {
+	case paging.cursorArg != "":
+		params += ",$cursor:String"
+		args += "," + paging.cursorArg + ":$cursor"
+		fields += " pageInfo{endCursor hasNextPage}"
+		if cursor != "" {
+			variables["cursor"] = cursor
+		}
+	case paging.offsetArg != "":
+		params += ",$offset:Int"
+		args += "," + paging.offsetArg + ":$offset"
+		variables["offset"] = offset
+	}
+
+	query := "query MyQuery(" + params + "){helpObjectStore_searchArticles(" + args + "){...on HelpObjectStoreArticleSearchResults{" + fields + "}}}"
+
 	payload := map[string]interface{}{
 		"query":         query,
 		"operationName": "MyQuery",
@@ -262,35 +552,41 @@ func searchDocuments(client *Client, cloudID, queryTerm string, limit int) (int,
 
 	resp, err := client.post("/gateway/api/graphql", payload)
 	if err != nil {
-		return 0, nil, fmt.Errorf("execute request: %w", err)
+		return nil, fmt.Errorf("execute request: %w", err)
 	}
 
 	if resp.StatusCode != 200 {
 		body, _ := readBody(resp)
-		return 0, nil, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, string(body))
+		switch {
+		case resp.StatusCode == 401 || resp.StatusCode == 403:
+			return nil, fmt.Errorf("%w: status %d", errGraphQLAuth, resp.StatusCode)
+		case resp.StatusCode == 429:
+			return nil, fmt.Errorf("%w: status 429", errGraphQLRateLimit)
+		case resp.StatusCode == 400 && strings.Contains(strings.ToLower(string(body)), "limit"):
+			return nil, fmt.Errorf("%w: %s", errLimitRejected, string(body))
+		}
+		return nil, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, string(body))
 	}
 
 	var response DocsGraphQLResponse
 	if err := unmarshalJSON(resp, &response); err != nil {
-		return 0, nil, fmt.Errorf("parse response: %w", err)
+		return nil, fmt.Errorf("parse response: %w", err)
 	}
 
-	if len(response.Errors) > 0 {
-		return 0, nil, fmt.Errorf("GraphQL errors: %v", response.Errors)
+	if len(response.Errors) == 0 {
+		return &response, nil
 	}
 
-	docs := make([]Document, 0, len(response.Data.HelpObjectStoreSearchArticles.Results))
-	for _, result := range response.Data.HelpObjectStoreSearchArticles.Results {
-		docs = append(docs, Document{
-			ARI:           result.ARI,
-			Title:         result.Title,
-			AbsoluteURL:   result.AbsoluteURL,
-			ContainerARI:  result.ContainerARI,
-			ContainerName: result.ContainerName,
-		})
+	err = classifyGraphQLErrors(response.Errors)
+
+	// GraphQL can return data alongside errors; only schema errors make
+	// that data untrustworthy
+	results := response.Data.HelpObjectStoreSearchArticles
+	if len(results.Results) > 0 && !errors.Is(err, errGraphQLSchema) {
+		return &response, fmt.Errorf("%w: %v", errGraphQLPartial, err)
 	}
 
-	return response.Data.HelpObjectStoreSearchArticles.TotalCount, docs, nil
+	return nil, err
 }
 
 func getCloudID(client *Client) (string, error) {
@@ -317,12 +613,18 @@ func writeDocsToCSV(docs []Document, outputPath string) error {
 	writer := csv.NewWriter(file)
 	defer writer.Flush()
 
-	if err := writer.Write([]string{"ARI", "Title", "URL", "Container", "Container ARI"}); err != nil {
+	if err := writer.Write([]string{"ARI", "Title", "URL", "Container", "Container ARI", "Content", "Words", "Last Modified", "Keywords", "Help Centers", "Attachments", "Exposure",
+		"Resource Owner", "Cloud ID", "Resource Type", "Workspace", "Page ID", "Space ID", "Space Key", "Page REST URL", "Page UI URL", "Space REST URL", "Space UI URL"}); err != nil {
 		return fmt.Errorf("write CSV header: %w", err)
 	}
 
 	for _, doc := range docs {
-		if err := writer.Write([]string{doc.ARI, doc.Title, doc.AbsoluteURL, doc.ContainerName, doc.ContainerARI}); err != nil {
+		words := ""
+		if doc.ContentPath != "" {
+			words = strconv.Itoa(doc.WordCount)
+		}
+		if err := writer.Write([]string{doc.ARI, doc.Title, doc.AbsoluteURL, doc.ContainerName, doc.ContainerARI, doc.ContentPath, words, doc.LastModified, strings.Join(doc.Keywords, ";"), strings.Join(doc.HelpCenters, ";"), strconv.Itoa(len(doc.Attachments)), doc.Exposure,
+			doc.ResourceOwner, doc.CloudID, doc.ResourceType, doc.Workspace, doc.PageID, doc.SpaceID, doc.SpaceKey, doc.PageRESTURL, doc.PageUIURL, doc.SpaceRESTURL, doc.SpaceUIURL}); err != nil {
 			return fmt.Errorf("write CSV row: %w", err)
 		}
 	}
@@ -340,5 +642,35 @@ func printDocuments(docs []Document) {
 		fmt.Println("  Title: " + doc.Title)
 		fmt.Println("  URL: " + doc.AbsoluteURL)
 		fmt.Println("  Container: " + doc.ContainerName + " (" + doc.ContainerARI + ")")
+		if len(doc.HelpCenters) > 0 {
+			fmt.Println("  Help Centers: " + strings.Join(doc.HelpCenters, ", "))
+		}
+		if doc.PageID != "" {
+			fmt.Printf("  Page: %s (%s, cloud %s)\n", doc.PageID, doc.ResourceType, doc.CloudID)
+			fmt.Println("  Confluence: " + doc.PageUIURL)
+			fmt.Println("  REST: " + doc.PageRESTURL)
+		}
+		if doc.SpaceID != "" {
+			fmt.Println("  Space REST: " + doc.SpaceRESTURL)
+		}
+		if doc.SpaceKey != "" {
+			fmt.Println("  Space: " + doc.SpaceUIURL)
+		}
+		if doc.ContentPath != "" {
+			fmt.Printf("  Content: %s (%d words, modified %s)\n", doc.ContentPath, doc.WordCount, doc.LastModified)
+		}
+		if doc.Exposure != "" {
+			fmt.Println("  Exposure: " + doc.Exposure)
+		}
+		if len(doc.Keywords) > 0 {
+			fmt.Printf("  Keywords (%d): %s\n", len(doc.Keywords), strings.Join(doc.Keywords, ", "))
+		}
+		for _, a := range doc.Attachments {
+			fmt.Printf("  Attachment: %s (%s, %d bytes)", a.Name, a.MediaType, a.Size)
+			if a.SHA256 != "" {
+				fmt.Printf(" sha256:%s", a.SHA256)
+			}
+			fmt.Println()
+		}
 	}
 }
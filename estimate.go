@@ -0,0 +1,208 @@
+// Copyright 2025 İrem Kuyucu
+// Copyright 2025 Laurynas Četyrkinas
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	branchA = 1 << iota
+	branchB
+)
+
+// coverageTracker collects per-prefix saturation and branch overlap
+// statistics for a single enumeration run. Prefix branches are split into
+// two groups by their first character, and users seen in both groups are
+// the recaptures of a two-sample capture-recapture estimate.
+type coverageTracker struct {
+	queries    int
+	saturated  int
+	unexpanded int
+	failed     int
+	rooted     bool
+	exclude    string
+	seen       map[string]uint8
+}
+
+type populationEstimate struct {
+	Observed   int
+	Collected  int
+	Estimate   float64
+	Low        float64
+	High       float64
+	Coverage   float64
+	Exhaustive bool
+}
+
+// DeskEstimate is the coverage summary of one enumerated desk
+type DeskEstimate struct {
+	DeskID     string
+	Queries    int
+	Saturated  int
+	Unexpanded int
+	Failed     int
+	Population populationEstimate
+}
+
+func newCoverageTracker(excludeAccountID string) *coverageTracker {
+	return &coverageTracker{exclude: excludeAccountID, seen: make(map[string]uint8)}
+}
+
+func (t *coverageTracker) record(query string, users []User, saturated bool) {
+	t.queries++
+	if saturated {
+		t.saturated++
+	}
+
+	// The empty query belongs to no branch
+	var branch uint8
+	if query == "" {
+		t.rooted = true
+	} else {
+		r, _ := utf8.DecodeRuneInString(strings.ToLower(query))
+		branch = branchA
+		if r%2 == 1 {
+			branch = branchB
+		}
+	}
+
+	for _, user := range users {
+		if user.AccountID == t.exclude {
+			continue
+		}
+		t.seen[user.AccountID] |= branch
+	}
+}
+
+// skipExpansion records a saturated prefix that was never expanded, e.g.
+// because the run was capped or interrupted
+func (t *coverageTracker) skipExpansion() {
+	t.unexpanded++
+}
+
+// fail records a query that returned an error, so its users and any
+// expansion below it were never seen
+func (t *coverageTracker) fail() {
+	t.queries++
+	t.failed++
+}
+
+func (t *coverageTracker) summary(deskID string, collected int, completed bool) DeskEstimate {
+	return DeskEstimate{
+		DeskID:     deskID,
+		Queries:    t.queries,
+		Saturated:  t.saturated,
+		Unexpanded: t.unexpanded,
+		Failed:     t.failed,
+		Population: t.estimate(collected, completed),
+	}
+}
+
+// estimate returns the Chapman estimate of the population size with a 95%
+// confidence interval. A run that expanded every saturated prefix to
+// completion without failed queries has seen the whole population and is
+// reported as exhaustive. That needs the empty query as its root; runs from
+// seeds or a custom query only cover what those prefixes match.
+func (t *coverageTracker) estimate(collected int, completed bool) populationEstimate {
+	est := populationEstimate{Observed: len(t.seen), Collected: collected}
+
+	if completed && t.rooted && t.unexpanded == 0 && t.failed == 0 {
+		est.Exhaustive = true
+		est.Estimate = float64(est.Observed)
+		est.Low, est.High = est.Estimate, est.Estimate
+		est.Coverage = coverage(collected, est.Estimate)
+		return est
+	}
+
+	var n1, n2, m float64
+	for _, branches := range t.seen {
+		if branches&branchA != 0 {
+			n1++
+		}
+		if branches&branchB != 0 {
+			n2++
+		}
+		if branches == branchA|branchB {
+			m++
+		}
+	}
+
+	if n1 == 0 || n2 == 0 {
+		return est
+	}
+
+	n := (n1+1)*(n2+1)/(m+1) - 1
+	variance := (n1 + 1) * (n2 + 1) * (n1 - m) * (n2 - m) / ((m + 1) * (m + 1) * (m + 2))
+	margin := 1.96 * math.Sqrt(variance)
+
+	est.Estimate = math.Max(n, float64(est.Observed))
+	est.Low = math.Max(n-margin, float64(est.Observed))
+	est.High = math.Max(n+margin, est.Low)
+	est.Coverage = coverage(collected, est.Estimate)
+	return est
+}
+
+func coverage(collected int, total float64) float64 {
+	if total <= 0 {
+		return 0
+	}
+	return math.Min(100, 100*float64(collected)/total)
+}
+
+func (e populationEstimate) String() string {
+	if e.Exhaustive {
+		return fmt.Sprintf("exhaustive, %d user(s) (coverage %.0f%%)", e.Observed, e.Coverage)
+	}
+	if e.Estimate == 0 {
+		return fmt.Sprintf("at least %d user(s), not enough branch overlap to estimate", e.Observed)
+	}
+	return fmt.Sprintf("~%.0f user(s) (95%% CI %.0f-%.0f), coverage %.0f%%", e.Estimate, e.Low, e.High, e.Coverage)
+}
+
+func writeEstimatesToCSV(estimates []DeskEstimate, outputPath string) error {
+	file, err := os.Create(outputPath)
+	if err != nil {
+		return fmt.Errorf("create CSV file: %w", err)
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	defer writer.Flush()
+
+	if err := writer.Write([]string{"Desk", "Queries", "Saturated", "Unexpanded", "Failed", "Observed", "Collected", "Estimate", "Low", "High", "Coverage", "Exhaustive"}); err != nil {
+		return fmt.Errorf("write CSV header: %w", err)
+	}
+
+	for _, e := range estimates {
+		p := e.Population
+		row := []string{e.DeskID, strconv.Itoa(e.Queries), strconv.Itoa(e.Saturated), strconv.Itoa(e.Unexpanded), strconv.Itoa(e.Failed),
+			strconv.Itoa(p.Observed), strconv.Itoa(p.Collected), fmt.Sprintf("%.0f", p.Estimate), fmt.Sprintf("%.0f", p.Low), fmt.Sprintf("%.0f", p.High),
+			fmt.Sprintf("%.0f", p.Coverage), strconv.FormatBool(p.Exhaustive)}
+		if err := writer.Write(row); err != nil {
+			return fmt.Errorf("write CSV row: %w", err)
+		}
+	}
+
+	fmt.Printf("\nWrote %d desk estimate(s) to %s\n", len(estimates), outputPath)
+	return nil
+}
@@ -0,0 +1,222 @@
+// Copyright 2025 İrem Kuyucu
+// Copyright 2025 Laurynas Četyrkinas
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	exposureAnonymous = "anonymous-visible"
+	exposureCustomer  = "customer-visible"
+	exposureTenant    = "tenant-only"
+
+	// exposureUnverified marks data only returned by queries that were not
+	// replayed, so its anonymous visibility is unknown
+	exposureUnverified = "unverified"
+)
+
+// sessionExposure is the label for data seen only with the current session.
+// Tenant-only follows from --tenantsession alone; it is never measured
+// against a customer session.
+func sessionExposure() string {
+	if *tenantSession {
+		return exposureTenant
+	}
+	return exposureCustomer
+}
+
+// sampleSize returns how many of total queries to replay, or all of them
+// if sample is 0
+func sampleSize(total, sample int) int {
+	if sample > 0 && sample < total {
+		return sample
+	}
+	return total
+}
+
+// replayCount returns how many of total queries to replay, limited by the
+// sample size and the request budget, and charges them to the budget
+func replayCount(total, sample int, limits *budget) int {
+	n := sampleSize(total, sample)
+	spent := limits.spendUpTo(n)
+	if spent < n {
+		fmt.Printf("\nRequest budget allows replaying %d of %d queries\n", spent, n)
+	}
+	return spent
+}
+
+// compareDocsAnonymous replays search queries without a session and labels
+// every document by the weakest context that can see it. Documents no
+// successfully replayed query returned to the session are labelled
+// unverified.
+func compareDocsAnonymous(baseURL string, timeout time.Duration, backend *docsBackend, tasks []searchTask, sample int, docs []Document, workers int, limits *budget) {
+	tasks = tasks[:replayCount(len(tasks), sample, limits)]
+	fmt.Printf("\nReplaying %d queries without a session\n", len(tasks))
+
+	anonClient := newClient(baseURL, "", timeout)
+	covered := make(map[string]bool)
+	visible := make(map[string]bool)
+	var replay replayStats
+	var mu sync.Mutex
+
+	runPool(len(tasks), workers, func(i int) {
+		task := tasks[i]
+		_, found, err := backend.search(anonClient, task.helpCenter, task.query)
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		// Partial results still prove visibility, but not the absence of it
+		for _, doc := range found {
+			visible[doc.ARI] = true
+		}
+		if !replay.record(err) {
+			return
+		}
+		for _, ari := range task.found {
+			covered[ari] = true
+		}
+	})
+	replay.report()
+
+	anonymous, unverified := 0, 0
+	for i := range docs {
+		switch {
+		case visible[docs[i].ARI]:
+			docs[i].Exposure = exposureAnonymous
+			anonymous++
+		case covered[docs[i].ARI]:
+			docs[i].Exposure = sessionExposure()
+		default:
+			docs[i].Exposure = exposureUnverified
+			unverified++
+		}
+	}
+
+	fmt.Printf("%d/%d document(s) are visible anonymously, %d unverified\n", anonymous, len(docs), unverified)
+}
+
+// compareUsersAnonymous replays user searches without a session and labels
+// every user by the weakest context that can see it. Users no successfully
+// replayed query returned to the session are labelled unverified.
+func compareUsersAnonymous(baseURL string, timeout time.Duration, tasks []userSearchTask, sample int, userMap map[string]User, workers int, limits *budget) {
+	tasks = tasks[:replayCount(len(tasks), sample, limits)]
+	fmt.Printf("\nReplaying %d queries without a session\n", len(tasks))
+
+	anonClient := newClient(baseURL, "", timeout)
+	covered := make(map[string]bool)
+	visible := make(map[string]bool)
+	var replay replayStats
+	var mu sync.Mutex
+
+	runPool(len(tasks), workers, func(i int) {
+		task := tasks[i]
+		found, err := searchUsers(anonClient, task.deskID, task.query)
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		for _, user := range found {
+			visible[user.AccountID] = true
+		}
+		if !replay.record(err) {
+			return
+		}
+		for _, id := range task.found {
+			covered[id] = true
+		}
+	})
+	replay.report()
+
+	anonymous, unverified := 0, 0
+	for id, user := range userMap {
+		switch {
+		case visible[id]:
+			user.Exposure = exposureAnonymous
+			anonymous++
+		case covered[id]:
+			user.Exposure = sessionExposure()
+		default:
+			user.Exposure = exposureUnverified
+			unverified++
+		}
+		userMap[id] = user
+	}
+
+	fmt.Printf("%d/%d user(s) are visible anonymously, %d unverified\n", anonymous, len(userMap), unverified)
+}
+
+// replayStats counts how anonymous replays ended. Denials are the expected
+// answer without a session, so only real failures are warned about.
+type replayStats struct {
+	denied int
+	failed int
+	last   error
+}
+
+// record classifies the error of one replay and reports whether the replay
+// answered the query, either with results or a clear denial
+func (r *replayStats) record(err error) bool {
+	var syntaxErr *json.SyntaxError
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, errAccessDenied) || errors.Is(err, errGraphQLAuth) || errors.As(err, &syntaxErr):
+		// A login page instead of JSON is a denial as well
+		r.denied++
+		return true
+	default:
+		r.failed++
+		r.last = err
+		return false
+	}
+}
+
+func (r *replayStats) report() {
+	if r.denied > 0 {
+		fmt.Printf("%d query(s) were denied without a session\n", r.denied)
+	}
+	if r.failed > 0 {
+		fmt.Fprintf(os.Stderr, "Warning: %d anonymous replay(s) failed, their results are unverified (last error: %v)\n", r.failed, r.last)
+	}
+}
+
+// runPool calls fn for every index below n on a pool of workers
+func runPool(n, workers int, fn func(i int)) {
+	indexes := make(chan int, n)
+	for i := 0; i < n; i++ {
+		indexes <- i
+	}
+	close(indexes)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				fn(i)
+			}
+		}()
+	}
+	wg.Wait()
+}
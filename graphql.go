@@ -0,0 +1,125 @@
+// Copyright 2025 İrem Kuyucu
+// Copyright 2025 Laurynas Četyrkinas
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+var (
+	errGraphQLAuth      = errors.New("GraphQL authorization failed")
+	errGraphQLRateLimit = errors.New("GraphQL rate limited")
+	errGraphQLSchema    = errors.New("GraphQL schema mismatch")
+	errGraphQLPartial   = errors.New("GraphQL partial result")
+)
+
+type GraphQLError struct {
+	Message    string        `json:"message"`
+	Path       []interface{} `json:"path"`
+	Extensions struct {
+		StatusCode     int    `json:"statusCode"`
+		ErrorType      string `json:"errorType"`
+		Classification string `json:"classification"`
+	} `json:"extensions"`
+}
+
+// SchemaError is returned when the gateway no longer knows a queried field
+type SchemaError struct {
+	Field   string
+	Message string
+}
+
+func (e *SchemaError) Error() string {
+	return fmt.Sprintf("field '%s' is not supported: %s", e.Field, e.Message)
+}
+
+func (e *SchemaError) Unwrap() error {
+	return errGraphQLSchema
+}
+
+var (
+	fieldNamePattern = regexp.MustCompile(`[Ff]ield '([^']+)'`)
+	fieldPathPattern = regexp.MustCompile(`@\[([^\]]+)\]`)
+
+	schemaWarnings sync.Map
+)
+
+// classifyGraphQLErrors turns the errors of a GraphQL response into a typed
+// error. The most actionable class wins: schema, auth, rate limit, limit.
+func classifyGraphQLErrors(errs []GraphQLError) error {
+	messages := make([]string, 0, len(errs))
+	var auth, rateLimit, limit bool
+	var schema *SchemaError
+
+	for _, e := range errs {
+		messages = append(messages, e.Message)
+		message := strings.ToLower(e.Message)
+		kind := strings.ToLower(e.Extensions.ErrorType + " " + e.Extensions.Classification)
+
+		switch {
+		case strings.Contains(kind, "validation") || strings.Contains(message, "undefined") || strings.Contains(message, "cannot query field"):
+			if schema == nil {
+				schema = &SchemaError{Field: fieldFromMessage(e.Message), Message: e.Message}
+			}
+		case e.Extensions.StatusCode == 401 || e.Extensions.StatusCode == 403 || strings.Contains(kind, "unauth") || strings.Contains(kind, "forbidden"):
+			auth = true
+		case e.Extensions.StatusCode == 429 || strings.Contains(kind, "ratelimit") || strings.Contains(message, "rate limit"):
+			rateLimit = true
+		case strings.Contains(message, "limit"):
+			limit = true
+		}
+	}
+
+	joined := strings.Join(messages, "; ")
+	switch {
+	case schema != nil:
+		return schema
+	case auth:
+		return fmt.Errorf("%w: %s", errGraphQLAuth, joined)
+	case rateLimit:
+		return fmt.Errorf("%w: %s", errGraphQLRateLimit, joined)
+	case limit:
+		return fmt.Errorf("%w: %s", errLimitRejected, joined)
+	default:
+		return fmt.Errorf("GraphQL errors: %s", joined)
+	}
+}
+
+// fieldFromMessage extracts the offending field from a validation message
+// such as "Field 'x' in type 'Y' is undefined" or "FieldUndefined@[a/b/x]"
+func fieldFromMessage(message string) string {
+	if m := fieldNamePattern.FindStringSubmatch(message); m != nil {
+		return m[1]
+	}
+	if m := fieldPathPattern.FindStringSubmatch(message); m != nil {
+		parts := strings.Split(m[1], "/")
+		return parts[len(parts)-1]
+	}
+	return "unknown"
+}
+
+// warnSchemaOnce reports each unsupported field a single time per run
+func warnSchemaOnce(err *SchemaError) {
+	if _, seen := schemaWarnings.LoadOrStore(err.Field, true); seen {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Warning: the gateway no longer supports field '%s' in article search (%s)\n", err.Field, err.Message)
+}
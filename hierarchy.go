@@ -0,0 +1,371 @@
+// Copyright 2025 İrem Kuyucu
+// Copyright 2025 Laurynas Četyrkinas
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+type ConfluenceContent struct {
+	ID         string `json:"id"`
+	Type       string `json:"type"`
+	Title      string `json:"title"`
+	Extensions struct {
+		MediaType string `json:"mediaType"`
+		FileSize  int64  `json:"fileSize"`
+	} `json:"extensions"`
+	Links struct {
+		WebUI    string `json:"webui"`
+		Download string `json:"download"`
+	} `json:"_links"`
+}
+
+type ConfluenceContentList struct {
+	Results []ConfluenceContent `json:"results"`
+	Links   struct {
+		Next string `json:"next"`
+	} `json:"_links"`
+}
+
+type ConfluenceAncestors struct {
+	Ancestors []ConfluenceContent `json:"ancestors"`
+}
+
+// HiddenContent is a page or attachment reachable from an exposed page that
+// the help center search did not return
+type HiddenContent struct {
+	ID       string
+	Type     string
+	Title    string
+	Relation string
+	Path     string
+	URL      string
+}
+
+type hierarchyTask struct {
+	pageID string
+	path   string
+}
+
+type hierarchyResult struct {
+	task    hierarchyTask
+	related []relatedContent
+	err     error
+}
+
+type relatedContent struct {
+	relation string
+	content  ConfluenceContent
+}
+
+// crawlHierarchy asks the Confluence REST API for the ancestors, children
+// and attachments of every exposed page, following newly found pages until
+// nothing new is reachable or the budget runs out. The deadline is the one
+// of the search phase, so the time budget covers both; zero means none.
+func crawlHierarchy(client *Client, docs []Document, workers int, limits *budget, deadline time.Time) []HiddenContent {
+	fmt.Printf("\nCrawling page hierarchy of %d document(s)\n", len(docs))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if !deadline.IsZero() {
+		ctx, cancel = context.WithDeadline(ctx, deadline)
+		defer cancel()
+	}
+
+	interruptedChan := setupSignalHandler(cancel)
+
+	known := make(map[string]bool)
+	var initialTasks []hierarchyTask
+	for _, doc := range docs {
+		if doc.PageID == "" || known[doc.PageID] {
+			continue
+		}
+		known[doc.PageID] = true
+		if limits.spendN(3) {
+			initialTasks = append(initialTasks, hierarchyTask{pageID: doc.PageID, path: doc.Title})
+		}
+	}
+
+	if len(initialTasks) == 0 {
+		return nil
+	}
+
+	var hidden []HiddenContent
+	taskQueue := make(chan hierarchyTask, 5000+len(initialTasks))
+	results := make(chan hierarchyResult, workers*2)
+
+	// Worker pool
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case task, ok := <-taskQueue:
+					if !ok {
+						return
+					}
+
+					related, err := relatedContents(client, task.pageID)
+
+					select {
+					case results <- hierarchyResult{task: task, related: related, err: err}:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+		}()
+	}
+
+	// Results processor - single goroutine processes all results
+	var processorWg sync.WaitGroup
+	processorWg.Add(1)
+	go func() {
+		defer processorWg.Done()
+		pendingTasks := len(initialTasks)
+		crawled := 0
+
+		// Follow-up pages can outgrow the queue, so they wait in the backlog
+		// and are fed in as slots free up. Blocking on a full queue would
+		// stall the workers, which wait for this goroutine to take results.
+		var backlog []hierarchyTask
+		feedBacklog := func() {
+			for len(backlog) > 0 {
+				select {
+				case <-ctx.Done():
+					return
+				case taskQueue <- backlog[0]:
+					backlog = backlog[1:]
+				default:
+					return
+				}
+			}
+		}
+
+		for result := range results {
+			pendingTasks--
+			crawled++
+
+			if result.err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: crawl of page %s failed: %v\n", result.task.pageID, result.err)
+			}
+
+			newItems := 0
+			for _, item := range result.related {
+				if known[item.content.ID] {
+					continue
+				}
+				known[item.content.ID] = true
+				newItems++
+
+				path := result.task.path + " > " + item.relation + ": " + item.content.Title
+				hidden = append(hidden, HiddenContent{
+					ID:       item.content.ID,
+					Type:     item.content.Type,
+					Title:    item.content.Title,
+					Relation: item.relation,
+					Path:     path,
+					URL:      confluenceURL(client.baseURL, item.content.Links.WebUI),
+				})
+
+				if item.content.Type == "page" && limits.spendN(3) {
+					pendingTasks++
+					backlog = append(backlog, hierarchyTask{pageID: item.content.ID, path: path})
+				}
+			}
+			feedBacklog()
+
+			fmt.Printf("[%d] Page: %s | Related: %3d | New: %3d | Hidden: %d | Pending: %d\n",
+				crawled, result.task.pageID, len(result.related), newItems, len(hidden), pendingTasks)
+
+			if pendingTasks == 0 {
+				cancel()
+				return
+			}
+		}
+	}()
+
+	for _, task := range initialTasks {
+		taskQueue <- task
+	}
+
+	go func() {
+		<-ctx.Done()
+		close(taskQueue)
+	}()
+
+	wg.Wait()
+	close(results)
+	processorWg.Wait()
+
+	select {
+	case <-interruptedChan:
+		fmt.Println("\n*** Interrupted by user ***")
+	default:
+	}
+
+	if reason := limits.stopReason(ctx); reason != "" {
+		fmt.Printf("\n*** Stopped early: %s ***\n", reason)
+	}
+
+	return hidden
+}
+
+// relatedContents returns the ancestors, child pages and attachments of a page
+func relatedContents(client *Client, pageID string) ([]relatedContent, error) {
+	var related []relatedContent
+
+	resp, err := client.get("/wiki/rest/api/content/" + pageID + "?expand=ancestors")
+	if err != nil {
+		return nil, fmt.Errorf("get ancestors: %w", err)
+	}
+	if resp.StatusCode == 200 {
+		var ancestors ConfluenceAncestors
+		if err := unmarshalJSON(resp, &ancestors); err != nil {
+			return nil, fmt.Errorf("parse ancestors: %w", err)
+		}
+		for _, content := range ancestors.Ancestors {
+			related = append(related, relatedContent{relation: "ancestor", content: content})
+		}
+	} else {
+		readBody(resp)
+	}
+
+	children, err := listContent(client, "/wiki/rest/api/content/"+pageID+"/child/page?limit=100")
+	if err != nil {
+		return related, fmt.Errorf("get children: %w", err)
+	}
+	for _, content := range children {
+		related = append(related, relatedContent{relation: "child", content: content})
+	}
+
+	attachments, err := listAttachments(client, pageID)
+	if err != nil {
+		return related, err
+	}
+	for _, content := range attachments {
+		related = append(related, relatedContent{relation: "attachment", content: content})
+	}
+
+	return related, nil
+}
+
+func listAttachments(client *Client, pageID string) ([]ConfluenceContent, error) {
+	attachments, err := listContent(client, "/wiki/rest/api/content/"+pageID+"/child/attachment?limit=100")
+	if err != nil {
+		return nil, fmt.Errorf("get attachments: %w", err)
+	}
+	return attachments, nil
+}
+
+// listContent follows the pagination links of a Confluence content list.
+// Pages the session cannot see yield an empty list rather than an error.
+func listContent(client *Client, path string) ([]ConfluenceContent, error) {
+	var contents []ConfluenceContent
+
+	for path != "" {
+		resp, err := client.get(path)
+		if err != nil {
+			return contents, err
+		}
+
+		if resp.StatusCode != 200 {
+			readBody(resp)
+			if resp.StatusCode == 403 || resp.StatusCode == 404 {
+				return contents, nil
+			}
+			return contents, fmt.Errorf("unexpected status %d", resp.StatusCode)
+		}
+
+		var list ConfluenceContentList
+		if err := unmarshalJSON(resp, &list); err != nil {
+			return contents, err
+		}
+		contents = append(contents, list.Results...)
+
+		path = list.Links.Next
+		if path != "" {
+			path = confluencePath(path)
+		}
+	}
+
+	return contents, nil
+}
+
+// confluencePath turns a link relative to the Confluence context into a
+// path relative to the site
+func confluencePath(link string) string {
+	if strings.HasPrefix(link, "/wiki/") {
+		return link
+	}
+	return "/wiki" + link
+}
+
+// confluenceURL turns a relative Confluence link into an absolute URL
+func confluenceURL(baseURL, link string) string {
+	if link == "" || strings.HasPrefix(link, "http") {
+		return link
+	}
+	return baseURL + confluencePath(link)
+}
+
+func writeHiddenToCSV(hidden []HiddenContent, outputPath string) error {
+	file, err := os.Create(outputPath)
+	if err != nil {
+		return fmt.Errorf("create CSV file: %w", err)
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	defer writer.Flush()
+
+	if err := writer.Write([]string{"ID", "Type", "Title", "Relation", "URL", "Discovery Path"}); err != nil {
+		return fmt.Errorf("write CSV header: %w", err)
+	}
+
+	for _, h := range hidden {
+		if err := writer.Write([]string{h.ID, h.Type, h.Title, h.Relation, h.URL, h.Path}); err != nil {
+			return fmt.Errorf("write CSV row: %w", err)
+		}
+	}
+
+	fmt.Printf("\nWrote %d unindexed items to %s\n", len(hidden), outputPath)
+	return nil
+}
+
+func printHidden(hidden []HiddenContent) {
+	fmt.Printf("\n\nUnindexed Content (%d):\n", len(hidden))
+	fmt.Println(strings.Repeat("-", 100))
+
+	for _, h := range hidden {
+		fmt.Printf("\n[%s] %s\n", h.Type, h.Title)
+		fmt.Println("  ID: " + h.ID)
+		if h.URL != "" {
+			fmt.Println("  URL: " + h.URL)
+		}
+		fmt.Println("  Path: " + h.Path)
+	}
+}
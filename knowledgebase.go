@@ -0,0 +1,210 @@
+// Copyright 2025 İrem Kuyucu
+// Copyright 2025 Laurynas Četyrkinas
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+const (
+	backendAuto        = "auto"
+	backendGraphQL     = "graphql"
+	backendServiceDesk = "servicedesk"
+
+	knowledgeBasePageLimit = 100
+)
+
+// knowledgeBaseHeaders opt in to the knowledge base search, which is an
+// experimental servicedesk API
+var knowledgeBaseHeaders = http.Header{"X-ExperimentalApi": {"opt-in"}}
+
+type KnowledgeBaseResponse struct {
+	Size       int  `json:"size"`
+	Start      int  `json:"start"`
+	Limit      int  `json:"limit"`
+	IsLastPage bool `json:"isLastPage"`
+	Values     []struct {
+		Title   string `json:"title"`
+		Excerpt string `json:"excerpt"`
+		Source  struct {
+			Type     string `json:"type"`
+			PageID   string `json:"pageId"`
+			SpaceKey string `json:"spaceKey"`
+		} `json:"source"`
+		Content struct {
+			IframeSrc string `json:"iframeSrc"`
+		} `json:"content"`
+	} `json:"values"`
+}
+
+// docsBackend searches articles either through the help center GraphQL
+// gateway (Cloud) or the servicedesk knowledge base REST API, which also
+// works on Data Center and when the gateway is blocked
+type docsBackend struct {
+	name    string
+	cloudID string
+	paging  *docsPaging
+}
+
+// selectDocsBackend resolves the backend to use. For auto the gateway is
+// preferred and the servicedesk API is used when it is unreachable or
+// refuses the search.
+func selectDocsBackend(client *Client, name string, pageSize int) (*docsBackend, error) {
+	backend := &docsBackend{name: name, paging: newDocsPaging(pageSize)}
+
+	cloudID, err := getCloudID(client)
+	switch {
+	case err == nil:
+		backend.cloudID = cloudID
+	case name == backendGraphQL:
+		return nil, fmt.Errorf("failed to get cloud ID: %w", err)
+	case name == backendAuto:
+		fmt.Printf("No cloud ID (%v), using the servicedesk backend\n", err)
+		backend.name = backendServiceDesk
+		return backend, nil
+	}
+
+	if backend.name == backendServiceDesk {
+		return backend, nil
+	}
+
+	backend.paging.detectPagination(client)
+
+	if backend.name == backendAuto {
+		backend.name = backendGraphQL
+		_, err := searchArticlesPage(client, cloudID, "", "", 1, backend.paging, 0, "")
+		if err != nil && !errors.Is(err, errGraphQLPartial) {
+			fmt.Printf("Gateway article search unavailable (%v), using the servicedesk backend\n", err)
+			backend.name = backendServiceDesk
+		}
+	}
+
+	return backend, nil
+}
+
+// helpCenters returns the sources to search: help centers on the gateway,
+// or the knowledge base of every service desk on the servicedesk API
+func (b *docsBackend) helpCenters(client *Client, explicit []string, argName string) []HelpCenter {
+	if b.name == backendGraphQL {
+		return discoverHelpCenters(client, b.cloudID, b.paging, explicit, argName)
+	}
+
+	if len(explicit) > 0 {
+		fmt.Fprintln(os.Stderr, "Warning: --help-center is ignored by the servicedesk backend")
+	}
+
+	desks, err := getServiceDesks(client)
+	if err != nil || len(desks) == 0 {
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v, searching the global knowledge base only\n", err)
+		}
+		return []HelpCenter{defaultHelpCenter}
+	}
+
+	helpCenters := make([]HelpCenter, 0, len(desks))
+	for _, desk := range desks {
+		helpCenters = append(helpCenters, HelpCenter{DeskID: desk.ID, Name: desk.ProjectName})
+	}
+
+	fmt.Printf("Searching the knowledge bases of %d service desk(s)\n", len(helpCenters))
+	return helpCenters
+}
+
+func (b *docsBackend) search(client *Client, helpCenter HelpCenter, queryTerm string) (int, []Document, error) {
+	if b.name == backendServiceDesk {
+		return searchKnowledgeBase(client, b.cloudID, helpCenter.DeskID, queryTerm)
+	}
+	return searchDocuments(client, b.cloudID, helpCenter.ARI, queryTerm, b.paging)
+}
+
+// searchKnowledgeBase returns the first page of knowledge base articles
+// matching the query term, across all linked knowledge bases or those of one
+// desk. The API reports no total, so a page that is not the last one is
+// counted one short of the total. The crawl then expands the query like a
+// truncated gateway search, and every query costs one request.
+func searchKnowledgeBase(client *Client, cloudID, deskID, queryTerm string) (int, []Document, error) {
+	path := "/rest/servicedeskapi/knowledgebase/article"
+	if deskID != "" {
+		path = "/rest/servicedeskapi/servicedesk/" + deskID + "/knowledgebase/article"
+	}
+
+	resp, err := client.getWithHeaders(fmt.Sprintf("%s?query=%s&highlight=false&limit=%d",
+		path, url.QueryEscape(queryTerm), knowledgeBasePageLimit), knowledgeBaseHeaders)
+	if err != nil {
+		return 0, nil, fmt.Errorf("execute request: %w", err)
+	}
+
+	if resp.StatusCode != 200 {
+		body, _ := readBody(resp)
+		switch resp.StatusCode {
+		case 404:
+			// Desks without a linked knowledge base have nothing to search
+			return 0, nil, nil
+		case 401, 403:
+			return 0, nil, fmt.Errorf("%w: status %d", errAccessDenied, resp.StatusCode)
+		}
+		return 0, nil, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var page KnowledgeBaseResponse
+	if err := unmarshalJSON(resp, &page); err != nil {
+		return 0, nil, fmt.Errorf("parse response: %w", err)
+	}
+
+	docs := make([]Document, 0, len(page.Values))
+	for _, article := range page.Values {
+		docs = append(docs, knowledgeBaseDocument(client.baseURL, cloudID, deskID, article.Title,
+			article.Source.PageID, article.Source.SpaceKey, article.Content.IframeSrc))
+	}
+
+	if page.IsLastPage || len(docs) == 0 {
+		return len(docs), docs, nil
+	}
+	return len(docs) + 1, docs, nil
+}
+
+// knowledgeBaseDocument builds a document from a knowledge base article. On
+// Cloud the page ARI is derived from the cloud ID; Data Center has no ARIs,
+// so the page ID identifies the document instead.
+func knowledgeBaseDocument(baseURL, cloudID, deskID, title, pageID, spaceKey, iframeSrc string) Document {
+	doc := Document{
+		ARI:           "page/" + pageID,
+		Title:         title,
+		ContainerName: spaceKey,
+		PageID:        pageID,
+		SpaceKey:      spaceKey,
+	}
+	if cloudID != "" {
+		doc.ARI = "ari:cloud:confluence:" + cloudID + ":page/" + pageID
+	}
+
+	switch {
+	case deskID != "":
+		doc.AbsoluteURL = baseURL + "/servicedesk/customer/portal/" + deskID + "/article/" + pageID
+	case strings.HasPrefix(iframeSrc, "/"):
+		doc.AbsoluteURL = baseURL + iframeSrc
+	default:
+		doc.AbsoluteURL = iframeSrc
+	}
+
+	annotateARIs(&doc, baseURL)
+	return doc
+}
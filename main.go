@@ -59,40 +59,74 @@ func printUsage() {
 
 func handleSignup() {
 	fs := flag.NewFlagSet("signup", flag.ExitOnError)
-	url := fs.String("url", "", "Jira URL (e.g., https://example.atlassian.net)")
+	common := addCommonFlags(fs)
 	email := fs.String("email", "", "Email address for signup")
+	probe := fs.Bool("probe", false, "Check whether self-signup is enabled without submitting an email")
 
 	fs.Parse(os.Args[2:])
 
-	if *url == "" || *email == "" {
+	if *common.url == "" || (*email == "" && !*probe) {
 		fmt.Fprintln(os.Stderr, "Error: --url and --email are required")
 		fs.Usage()
 		os.Exit(1)
 	}
 
-	if err := signup(*url, *email); err != nil {
+	if err := applyClientFlags(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *probe {
+		result, err := probeSignup(*common.url, *common.cookie, *common.timeout)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: signup probe failed: %v\n", err)
+			os.Exit(1)
+		}
+		printSignupProbe(result)
+		return
+	}
+
+	outcome, detail, err := signup(*common.url, *common.cookie, *email, *common.timeout)
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: signup failed: %v\n", err)
 		os.Exit(1)
 	}
 
-	fmt.Println("Signup successful, check email")
+	info := signupOutcomes[outcome]
+	fmt.Println("Outcome: " + info.name)
+	if detail != "" {
+		fmt.Println("Response: " + detail)
+	}
+	fmt.Println("Finding: " + info.finding)
+	os.Exit(info.exitCode)
 }
 
 func handlePermissions() {
 	fs := flag.NewFlagSet("permissions", flag.ExitOnError)
-	url := fs.String("url", "", "Jira URL (e.g., https://example.atlassian.net)")
-	cookie := fs.String("cookie", "", "Session cookie value (customer.account.session.token)")
-	tenantSession = fs.Bool("tenantsession", false, "Set session cookie name to tenant.session.token")
+	common := addCommonFlags(fs)
+	projects := fs.String("projects", "", "Check permissions per project: 'all' or comma-separated project keys (optional)")
+	output := fs.String("output", "", "Output CSV file path for permission results (optional)")
+	onlyGranted := fs.Bool("only-granted", false, "Only show permissions the session holds")
+	compare := fs.String("compare", "", "Diff permissions against a second auth context: 'anonymous' or another session cookie (optional)")
+	compareTenant := fs.Bool("compare-tenantsession", false, "The --compare cookie is a tenant.session.token")
+	compareOutput := fs.String("compare-output", "", "Output CSV file path for the permission diff (optional)")
+	verify := fs.Bool("verify", false, "Prove every granted permission with a read-only probe")
+	verifyOutput := fs.String("verify-output", "", "Output CSV file path for verification results (optional)")
 
 	fs.Parse(os.Args[2:])
 
-	if *url == "" || *cookie == "" {
+	if *common.url == "" || *common.cookie == "" {
 		fmt.Fprintln(os.Stderr, "Error: --url and --cookie are required")
 		fs.Usage()
 		os.Exit(1)
 	}
 
-	if err := checkPermissions(*url, *cookie); err != nil {
+	if err := applyClientFlags(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := checkPermissions(*common.url, *common.cookie, *common.timeout, *projects, *output, *verify, *verifyOutput, *onlyGranted, newComparisonOptions(*compare, *compareTenant, *compareOutput)); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: permission check failed: %v\n", err)
 		os.Exit(1)
 	}
@@ -100,33 +134,73 @@ func handlePermissions() {
 
 func handleUsers() {
 	fs := flag.NewFlagSet("users", flag.ExitOnError)
-	url := fs.String("url", "", "Jira URL (e.g., https://example.atlassian.net)")
-	cookie := fs.String("cookie", "", "Session cookie value (customer.account.session.token)")
+	common := addCommonFlags(fs)
 	maxUsers := fs.Int("max", 50, "Maximum users to fetch per service desk (0 = unlimited)")
 	deskID := fs.String("desk", "", "Specific service desk ID to enumerate (optional)")
 	query := fs.String("query", "", "Custom search query (optional, skips automatic enumeration)")
+	checkListPath := fs.String("check-list", "", "File of emails or display names to check for presence (optional, skips enumeration)")
+	seedsPath := fs.String("seeds", "", "File of seed queries (names, email prefixes) to search before prefix expansion (optional)")
+	scopeCheck := fs.Bool("scope-check", true, "Detect tenant-wide user search and enumerate equivalent desks once")
+	tokenSeeding := fs.Bool("token-seeding", false, "Queue name and email tokens of discovered users as new queries")
 	alphabet1 := fs.String("alphabet", "abcdefghijklmnopqrstuvwxyz0123456789", "Alphabet for layer 1 search expansion")
 	alphabet2 := fs.String("alphabet2", "abcdefghijklmnopqrstuvwxyz", "Alphabet for layer 2+ search expansion")
 	workers := fs.Int("workers", 10, "Number of concurrent workers")
-	timeout := fs.Int("timeout", 10, "HTTP request timeout in seconds")
 	output := fs.String("output", "", "Output CSV file path (optional)")
-	tenantSession = fs.Bool("tenantsession", false, "Set session cookie name to tenant.session.token")
+	maxRequests := fs.Int("max-requests", 0, "Maximum number of search requests (0 = unlimited)")
+	maxDepth := fs.Int("max-depth", 0, "Maximum prefix expansion depth (0 = unlimited)")
+	maxDuration := fs.Duration("max-duration", 0, "Maximum wall-clock run time, e.g. 10m (0 = unlimited)")
+	compareAnonymous := fs.Bool("compare-anonymous", false, "Replay queries without a session and label results by exposure")
+	compareSample := fs.Int("compare-sample", 0, "Number of queries to replay for --compare-anonymous (0 = all)")
+	estimateOutput := fs.String("estimate-output", "", "Output CSV file path for the per-desk coverage estimate (optional)")
 
 	fs.Parse(os.Args[2:])
 
-	if *url == "" || *cookie == "" {
+	if *common.url == "" || *common.cookie == "" {
 		fmt.Fprintln(os.Stderr, "Error: --url and --cookie are required")
 		fs.Usage()
 		os.Exit(1)
 	}
 
-	selfAccountID, err := extractAccountIDFromJWT(*cookie)
+	if err := applyClientFlags(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *checkListPath != "" {
+		entries, err := readLines(*checkListPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: could not read check list: %v\n", err)
+			os.Exit(1)
+		}
+
+		if err := checkUserList(*common.url, *common.cookie, entries, *deskID, *output, *workers, *common.timeout); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: presence check failed: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	selfAccountID, err := extractAccountIDFromJWT(*common.cookie)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: could not extract account ID from cookie: %v\n", err)
 		os.Exit(1)
 	}
 
-	if err := enumerateUsers(*url, *cookie, *maxUsers, *deskID, *query, *alphabet1, *alphabet2, selfAccountID, *output, *workers, *timeout); err != nil {
+	var seeds []string
+	if *seedsPath != "" {
+		if *query != "" {
+			fmt.Fprintln(os.Stderr, "Error: --seeds and --query are mutually exclusive")
+			os.Exit(1)
+		}
+
+		seeds, err = readLines(*seedsPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: could not read seeds: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if err := enumerateUsers(*common.url, *common.cookie, *maxUsers, *deskID, *query, seeds, *tokenSeeding, *scopeCheck, *alphabet1, *alphabet2, selfAccountID, *output, *workers, *common.timeout, newBudget(*maxRequests, *maxDepth, *maxDuration), *compareAnonymous, *compareSample, *estimateOutput); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: user enumeration failed: %v\n", err)
 		os.Exit(1)
 	}
@@ -134,24 +208,91 @@ func handleUsers() {
 
 func handleDocs() {
 	fs := flag.NewFlagSet("docs", flag.ExitOnError)
-	url := fs.String("url", "", "Jira URL (e.g., https://example.atlassian.net)")
-	cookie := fs.String("cookie", "", "Session cookie value (customer.account.session.token)")
+	common := addCommonFlags(fs)
 	alphabet1 := fs.String("alphabet", "abcdefghijklmnopqrstuvwxyz0123456789", "Alphabet for layer 1 search expansion")
 	alphabet2 := fs.String("alphabet2", "abcdefghijklmnopqrstuvwxyz", "Alphabet for layer 2+ search expansion")
 	workers := fs.Int("workers", 10, "Number of concurrent workers")
-	timeout := fs.Int("timeout", 10, "HTTP request timeout in seconds")
 	output := fs.String("output", "", "Output CSV file path (optional)")
-	tenantSession = fs.Bool("tenantsession", false, "Set session cookie name to tenant.session.token")
+	maxRequests := fs.Int("max-requests", 0, "Maximum number of search requests (0 = unlimited)")
+	maxDepth := fs.Int("max-depth", 0, "Maximum prefix expansion depth (0 = unlimited)")
+	maxDuration := fs.Duration("max-duration", 0, "Maximum wall-clock run time, e.g. 10m (0 = unlimited)")
+	fetchContent := fs.Bool("fetch-content", false, "Download the body of every discovered document")
+	contentDir := fs.String("content-dir", "docs-content", "Directory for downloaded document content")
+	scan := fs.Bool("scan", false, "Scan titles and fetched content for secrets and sensitive data")
+	rulesPath := fs.String("rules", "", "File of extra scan rules, one name:severity:regex per line (optional)")
+	findingsOutput := fs.String("findings", "", "Output CSV file path for scan findings (optional)")
+	keywordsPath := fs.String("keywords", "", "File of keywords to search instead of full enumeration, ranked by matches (optional)")
+	includeSpace := fs.String("include-space", "", "Comma-separated containers to restrict results to, by name, ARI or space ID (optional)")
+	excludeSpace := fs.String("exclude-space", "", "Comma-separated containers to exclude, by name, ARI or space ID (optional)")
+	groupSpaces := fs.Bool("group-by-space", false, "Print documents grouped by Confluence space")
+	spacesOutput := fs.String("spaces-output", "", "Output CSV file path for per-space statistics (optional)")
+	crawl := fs.Bool("crawl-hierarchy", false, "Crawl ancestors, children and attachments of exposed pages for unindexed content")
+	hierarchyOutput := fs.String("hierarchy-output", "", "Output CSV file path for unindexed content (optional)")
+	showAttachments := fs.Bool("attachments", false, "List attachments of every discovered document")
+	attachmentDir := fs.String("download-attachments", "", "Directory to download attachments into, one subdirectory per space (optional)")
+	attachmentMaxSize := fs.Int64("attachment-max-size", 10*1024*1024, "Maximum attachment size to download in bytes (0 = unlimited)")
+	attachmentTypes := fs.String("attachment-types", "", "Comma-separated media type prefixes to download, e.g. application/pdf,text/ (optional)")
+	attachmentsOutput := fs.String("attachments-output", "", "Output CSV file path for attachment metadata (optional)")
+	compareAnonymous := fs.Bool("compare-anonymous", false, "Replay queries without a session and label results by exposure")
+	compareSample := fs.Int("compare-sample", 0, "Number of queries to replay for --compare-anonymous (0 = all)")
+	pageSize := fs.Int("limit", defaultDocsLimit, "Article search page size; shrunk automatically if the gateway rejects it")
+	helpCenters := fs.String("help-center", "", "Comma-separated help center ARIs to search instead of discovering them (optional)")
+	helpCenterArg := fs.String("help-center-arg", "helpCenterAri", "Article search argument for --help-center ARIs when introspection is disabled")
+	backend := fs.String("backend", backendAuto, "Docs search backend: auto, graphql or servicedesk")
 
 	fs.Parse(os.Args[2:])
 
-	if *url == "" || *cookie == "" {
+	if *common.url == "" || *common.cookie == "" {
 		fmt.Fprintln(os.Stderr, "Error: --url and --cookie are required")
 		fs.Usage()
 		os.Exit(1)
 	}
 
-	if err := enumerateDocs(*url, *cookie, *alphabet1, *alphabet2, *output, *workers, *timeout); err != nil {
+	if err := applyClientFlags(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *backend != backendAuto && *backend != backendGraphQL && *backend != backendServiceDesk {
+		fmt.Fprintln(os.Stderr, "Error: --backend must be auto, graphql or servicedesk")
+		os.Exit(1)
+	}
+
+	dir := ""
+	if *fetchContent {
+		dir = *contentDir
+	}
+
+	var keywords []string
+	if *keywordsPath != "" {
+		var err error
+		keywords, err = readLines(*keywordsPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: could not read keywords: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	var rules []scanRule
+	if *scan {
+		rules = append(rules, defaultScanRules...)
+		if *rulesPath != "" {
+			extra, err := loadScanRules(*rulesPath)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: could not load scan rules: %v\n", err)
+				os.Exit(1)
+			}
+			rules = append(rules, extra...)
+		}
+	}
+
+	if err := enumerateDocs(*common.url, *common.cookie, *alphabet1, *alphabet2, *output, *workers, *common.timeout, newBudget(*maxRequests, *maxDepth, *maxDuration), dir, rules, *findingsOutput, keywords, newSpaceFilter(*includeSpace, *excludeSpace), *groupSpaces, *spacesOutput, *crawl, *hierarchyOutput, attachmentOptions{
+		enabled: *showAttachments || *attachmentDir != "",
+		dir:     *attachmentDir,
+		maxSize: *attachmentMaxSize,
+		types:   splitList(*attachmentTypes),
+		output:  *attachmentsOutput,
+	}, *compareAnonymous, *compareSample, *pageSize, splitList(*helpCenters), *helpCenterArg, *backend); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: document enumeration failed: %v\n", err)
 		os.Exit(1)
 	}
@@ -0,0 +1,136 @@
+// Copyright 2025 İrem Kuyucu
+// Copyright 2025 Laurynas Četyrkinas
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// commonFlags are the target and auth options every command accepts
+type commonFlags struct {
+	url     *string
+	cookie  *string
+	timeout *int
+}
+
+// clientOptions configure every HTTP client of a run. They are set once from
+// the command line, like tenantSession.
+type clientOptions struct {
+	retries   int
+	proxy     string
+	headers   headerFlags
+	userAgent string
+	rateLimit float64
+
+	proxyURL *url.URL
+	header   http.Header
+	limiter  *rateLimiter
+}
+
+var clientConfig = clientOptions{retries: 3}
+
+// headerFlags collects repeated --header "Name: value" flags
+type headerFlags []string
+
+func (h *headerFlags) String() string {
+	return strings.Join(*h, ", ")
+}
+
+func (h *headerFlags) Set(value string) error {
+	*h = append(*h, value)
+	return nil
+}
+
+// addCommonFlags registers the shared options on a command's flag set
+func addCommonFlags(fs *flag.FlagSet) commonFlags {
+	common := commonFlags{
+		url:     fs.String("url", "", "Jira URL (e.g., https://example.atlassian.net)"),
+		cookie:  fs.String("cookie", "", "Session cookie value (customer.account.session.token)"),
+		timeout: fs.Int("timeout", 10, "HTTP request timeout in seconds"),
+	}
+	tenantSession = fs.Bool("tenantsession", false, "Set session cookie name to tenant.session.token")
+
+	fs.IntVar(&clientConfig.retries, "retries", 3, "Retries for network errors and 5xx responses")
+	fs.StringVar(&clientConfig.proxy, "proxy", "", "HTTP(S) proxy URL, e.g. http://127.0.0.1:8080 (optional)")
+	fs.Var(&clientConfig.headers, "header", "Extra request header 'Name: value', repeatable (optional)")
+	fs.StringVar(&clientConfig.userAgent, "user-agent", "", "User-Agent header for every request (optional)")
+	fs.Float64Var(&clientConfig.rateLimit, "rate-limit", 0, "Maximum requests per second across all workers (0 = unlimited)")
+
+	return common
+}
+
+// applyClientFlags validates the shared options after parsing
+func applyClientFlags() error {
+	if clientConfig.retries < 0 {
+		return fmt.Errorf("--retries must not be negative")
+	}
+
+	if clientConfig.proxy != "" {
+		proxyURL, err := url.Parse(clientConfig.proxy)
+		if err != nil || proxyURL.Host == "" {
+			return fmt.Errorf("invalid --proxy %q", clientConfig.proxy)
+		}
+		clientConfig.proxyURL = proxyURL
+	}
+
+	clientConfig.header = make(http.Header)
+	for _, header := range clientConfig.headers {
+		name, value, ok := strings.Cut(header, ":")
+		if !ok || strings.TrimSpace(name) == "" {
+			return fmt.Errorf("invalid --header %q, expected 'Name: value'", header)
+		}
+		clientConfig.header.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+	}
+
+	if clientConfig.rateLimit < 0 {
+		return fmt.Errorf("--rate-limit must not be negative")
+	}
+	if clientConfig.rateLimit > 0 {
+		clientConfig.limiter = &rateLimiter{interval: time.Duration(float64(time.Second) / clientConfig.rateLimit)}
+	}
+
+	return nil
+}
+
+// rateLimiter spaces requests of all clients evenly
+type rateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+func (l *rateLimiter) wait() {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	delay := l.next.Sub(now)
+	l.next = l.next.Add(l.interval)
+	l.mu.Unlock()
+
+	time.Sleep(delay)
+}
@@ -0,0 +1,174 @@
+// Copyright 2025 İrem Kuyucu
+// Copyright 2025 Laurynas Četyrkinas
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+const (
+	defaultDocsLimit = 2147483647
+	minDocsLimit     = 10
+	fallbackLimit    = 1000
+)
+
+// errLimitRejected marks a search the gateway refused because of its limit
+var errLimitRejected = errors.New("limit rejected")
+
+// docsPaging holds the article search page size, adapted to what the gateway
+// accepts and returns, and the pagination and help center arguments the
+// schema exposes if any. Workers share it, so every access to the limit goes
+// through the mutex.
+type docsPaging struct {
+	mu            sync.Mutex
+	limit         int
+	serverMax     int
+	offsetArg     string
+	cursorArg     string
+	helpCenterArg string
+}
+
+func newDocsPaging(limit int) *docsPaging {
+	if limit <= 0 {
+		limit = defaultDocsLimit
+	}
+	return &docsPaging{limit: limit}
+}
+
+func (p *docsPaging) currentLimit() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.limit
+}
+
+// shrink lowers the limit after the gateway rejected it, returning false if
+// it cannot go any lower. Concurrent rejections of the same limit shrink once.
+func (p *docsPaging) shrink(rejected int) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.limit < rejected {
+		return true
+	}
+	if p.limit <= minDocsLimit {
+		return false
+	}
+
+	if p.limit > fallbackLimit {
+		p.limit = fallbackLimit
+	} else {
+		p.limit = max(p.limit/2, minDocsLimit)
+	}
+	fmt.Printf("Gateway rejected limit %d, retrying with %d\n", rejected, p.limit)
+	return true
+}
+
+// observe detects a server-side cap: a page shorter than both the requested
+// limit and the total count means the server truncated it. Later requests
+// ask for no more than the cap, so pages line up with what is returned.
+func (p *docsPaging) observe(limit, returned, totalCount int) {
+	if returned == 0 || returned >= limit || returned >= totalCount {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if returned > p.serverMax {
+		p.serverMax = returned
+		fmt.Printf("Server caps article search at %d result(s) per query\n", returned)
+	}
+	if p.limit > p.serverMax {
+		p.limit = p.serverMax
+	}
+}
+
+func (p *docsPaging) paginated() bool {
+	return p.offsetArg != "" || p.cursorArg != ""
+}
+
+type introspectionResponse struct {
+	Data struct {
+		Query struct {
+			Fields []struct {
+				Name string `json:"name"`
+				Args []struct {
+					Name string `json:"name"`
+				} `json:"args"`
+			} `json:"fields"`
+		} `json:"query"`
+		Results struct {
+			Fields []struct {
+				Name string `json:"name"`
+			} `json:"fields"`
+		} `json:"results"`
+	} `json:"data"`
+}
+
+// detectPagination introspects the gateway schema for an offset or cursor
+// argument on article search. Introspection is often disabled, in which case
+// the search falls back to prefix expansion alone.
+func (p *docsPaging) detectPagination(client *Client) {
+	payload := map[string]interface{}{
+		"query": `query{query:__type(name:"Query"){fields{name args{name}}} results:__type(name:"HelpObjectStoreArticleSearchResults"){fields{name}}}`,
+	}
+
+	resp, err := client.post("/gateway/api/graphql", payload)
+	if err != nil {
+		return
+	}
+
+	var schema introspectionResponse
+	if resp.StatusCode != 200 || unmarshalJSON(resp, &schema) != nil {
+		readBody(resp)
+		return
+	}
+
+	hasPageInfo := false
+	for _, field := range schema.Data.Results.Fields {
+		if field.Name == "pageInfo" {
+			hasPageInfo = true
+		}
+	}
+
+	for _, field := range schema.Data.Query.Fields {
+		if field.Name != "helpObjectStore_searchArticles" {
+			continue
+		}
+		for _, arg := range field.Args {
+			switch arg.Name {
+			case "offset", "start":
+				p.offsetArg = arg.Name
+			case "after", "cursor":
+				if hasPageInfo {
+					p.cursorArg = arg.Name
+				}
+			case "helpCenterAri", "helpCenterId":
+				p.helpCenterArg = arg.Name
+			}
+		}
+	}
+
+	switch {
+	case p.cursorArg != "":
+		p.offsetArg = ""
+		fmt.Printf("Article search supports cursor pagination (%s)\n", p.cursorArg)
+	case p.offsetArg != "":
+		fmt.Printf("Article search supports offset pagination (%s)\n", p.offsetArg)
+	}
+}
@@ -16,7 +16,11 @@
 package main
 
 import (
+	"encoding/csv"
 	"fmt"
+	"net/url"
+	"os"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -45,8 +49,8 @@ type MyPermission struct {
 	HavePermission bool   `json:"havePermission"`
 }
 
-func checkPermissions(baseURL, cookie string) error {
-	client := newClient(baseURL, "", 10*time.Second)
+func checkPermissions(baseURL, cookie string, timeout int, projects, output string, verify bool, verifyOutput string, onlyGranted bool, compare comparisonOptions) error {
+	client := newClient(baseURL, "", time.Duration(timeout)*time.Second)
 
 	resp, err := client.get("/rest/api/3/permissions")
 	if err != nil {
@@ -64,27 +68,214 @@ func checkPermissions(baseURL, cookie string) error {
 	}
 
 	client.cookie = cookie
-	queryString := strings.Join(permKeys, ",")
-	resp, err = client.get("/rest/api/3/mypermissions?permissions=" + queryString)
+	global, err := getMyPermissions(client, permKeys, "")
 	if err != nil {
-		return fmt.Errorf("get my permissions: %w", err)
+		return err
+	}
+
+	var projectKeys []string
+	if projects != "" {
+		projectKeys = resolveProjects(client, projects)
+	}
+
+	matrix := permissionMatrix{
+		contexts: []string{"Global"},
+		grants:   map[string]map[string]MyPermission{"Global": global},
+	}
+	checkProjectPermissions(client, permKeys, projectKeys, &matrix)
+	matrix.keys = matrix.sortedKeys(permKeys, onlyGranted)
+
+	switch {
+	case output != "":
+		if err := writePermissionMatrixToCSV(matrix, output); err != nil {
+			return err
+		}
+	case projects == "":
+		printPermissions(matrix)
+	default:
+		printPermissionMatrix(matrix)
+	}
+
+	if compare.enabled {
+		if err := comparePermissions(baseURL, timeout, permKeys, projectKeys, matrix, compare); err != nil {
+			return err
+		}
+	}
+
+	if !verify {
+		return nil
+	}
+
+	verifications := verifyPermissions(client, global)
+	if verifyOutput != "" {
+		return writeVerificationsToCSV(verifications, verifyOutput)
+	}
+
+	printVerifications(verifications)
+	return nil
+}
+
+// checkProjectPermissions adds one context per project to the matrix
+func checkProjectPermissions(client *Client, permKeys, projectKeys []string, matrix *permissionMatrix) {
+	for _, projectKey := range projectKeys {
+		perms, err := getMyPermissions(client, permKeys, projectKey)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: permissions for project %s: %v\n", projectKey, err)
+			continue
+		}
+		matrix.contexts = append(matrix.contexts, projectKey)
+		matrix.grants[projectKey] = perms
+	}
+}
+
+// getMyPermissions asks which of the given permissions the session holds,
+// globally or within one project
+func getMyPermissions(client *Client, permKeys []string, projectKey string) (map[string]MyPermission, error) {
+	path := "/rest/api/3/mypermissions?permissions=" + strings.Join(permKeys, ",")
+	if projectKey != "" {
+		path += "&projectKey=" + url.QueryEscape(projectKey)
+	}
+
+	resp, err := client.get(path)
+	if err != nil {
+		return nil, fmt.Errorf("get my permissions: %w", err)
+	}
+
+	if resp.StatusCode != 200 {
+		readBody(resp)
+		return nil, fmt.Errorf("get my permissions: unexpected status %d", resp.StatusCode)
 	}
 
 	var myPermsResp MyPermissionsResponse
 	if err := unmarshalJSON(resp, &myPermsResp); err != nil {
-		return fmt.Errorf("parse my permissions: %w", err)
+		return nil, fmt.Errorf("parse my permissions: %w", err)
 	}
 
+	return myPermsResp.Permissions, nil
+}
+
+// permissionMatrix holds the grants of every permission key per context,
+// the global context first and then one per project
+type permissionMatrix struct {
+	keys     []string
+	contexts []string
+	grants   map[string]map[string]MyPermission
+}
+
+func (m permissionMatrix) has(context, key string) bool {
+	return m.grants[context][key].HavePermission
+}
+
+// granted reports whether the permission is held in any context
+func (m permissionMatrix) granted(key string) bool {
+	for _, context := range m.contexts {
+		if m.has(context, key) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m permissionMatrix) permission(key string) MyPermission {
+	for _, context := range m.contexts {
+		if perm, ok := m.grants[context][key]; ok {
+			return perm
+		}
+	}
+	return MyPermission{Key: key}
+}
+
+// sortedKeys returns the keys with dangerous grants first, optionally
+// dropping permissions held in no context
+func (m permissionMatrix) sortedKeys(permKeys []string, onlyGranted bool) []string {
+	keys := make([]string, 0, len(permKeys))
+	for _, key := range permKeys {
+		if !onlyGranted || m.granted(key) {
+			keys = append(keys, key)
+		}
+	}
+	sortPermissionKeys(keys, m.granted, m.permission)
+	return keys
+}
+
+func writePermissionMatrixToCSV(matrix permissionMatrix, outputPath string) error {
+	file, err := os.Create(outputPath)
+	if err != nil {
+		return fmt.Errorf("create CSV file: %w", err)
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	defer writer.Flush()
+
+	header := append([]string{"Permission", "Risk"}, matrix.contexts...)
+	header = append(header, "Impact", "Remediation")
+	if err := writer.Write(header); err != nil {
+		return fmt.Errorf("write CSV header: %w", err)
+	}
+
+	for _, key := range matrix.keys {
+		risk := riskOf(matrix.permission(key))
+		row := []string{key, risk.severity.String()}
+		for _, context := range matrix.contexts {
+			row = append(row, strconv.FormatBool(matrix.has(context, key)))
+		}
+		row = append(row, risk.impact, risk.remediation)
+		if err := writer.Write(row); err != nil {
+			return fmt.Errorf("write CSV row: %w", err)
+		}
+	}
+
+	fmt.Printf("\nWrote %d permissions across %d context(s) to %s\n", len(matrix.keys), len(matrix.contexts), outputPath)
+	return nil
+}
+
+func printPermissions(matrix permissionMatrix) {
 	fmt.Println("\nPermissions:")
 	fmt.Println(strings.Repeat("-", 80))
 
-	for _, perm := range myPermsResp.Permissions {
+	for _, key := range matrix.keys {
+		perm := matrix.permission(key)
+		risk := riskOf(perm)
+
 		status := "✗"
 		if perm.HavePermission {
 			status = "✓"
 		}
-		fmt.Printf("[%s] %-30s %-15s %s\n", status, perm.Name, "("+perm.Type+")", perm.Key)
+		fmt.Printf("[%s] %-8s %-30s %-15s %s\n", status, risk.severity, perm.Name, "("+perm.Type+")", perm.Key)
+
+		if perm.HavePermission {
+			if risk.impact != "" {
+				fmt.Println("      Impact: " + risk.impact)
+			}
+			fmt.Println("      Remediation: " + risk.remediation)
+		}
 	}
+}
 
-	return nil
+func printPermissionMatrix(matrix permissionMatrix) {
+	width := 0
+	for _, key := range matrix.keys {
+		width = max(width, len(key))
+	}
+
+	fmt.Println("\nPermissions by project:")
+	fmt.Printf("%-*s  %-8s", width, "Permission", "Risk")
+	for _, context := range matrix.contexts {
+		fmt.Printf("  %-*s", len(context), context)
+	}
+	fmt.Println()
+	fmt.Println(strings.Repeat("-", width+10+len(matrix.contexts)*10))
+
+	for _, key := range matrix.keys {
+		fmt.Printf("%-*s  %-8s", width, key, riskOf(matrix.permission(key)).severity)
+		for _, context := range matrix.contexts {
+			status := "✗"
+			if matrix.has(context, key) {
+				status = "✓"
+			}
+			fmt.Printf("  %-*s", len(context), status)
+		}
+		fmt.Println()
+	}
 }
@@ -0,0 +1,233 @@
+// Copyright 2025 İrem Kuyucu
+// Copyright 2025 Laurynas Četyrkinas
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// portalModels are the customer portal models that carry login, signup and
+// access settings; the portal itself requests them before anyone logs in
+var portalModels = []string{"login", "signup", "user", "portal", "sharedPortal", "helpCenterBranding"}
+
+type signupSetting int
+
+const (
+	settingSelfSignup signupSetting = iota
+	settingDomainRestricted
+	settingDomains
+	settingAnonymousAccess
+)
+
+// signupFields are the known portal model fields that carry a setting,
+// keyed by lowercased name. Field names differ between Cloud and Data
+// Center, so each setting has several.
+var signupFields = map[string]signupSetting{
+	"cansignup":                 settingSelfSignup,
+	"signupenabled":             settingSelfSignup,
+	"selfsignupenabled":         settingSelfSignup,
+	"publicsignup":              settingSelfSignup,
+	"publicsignupenabled":       settingSelfSignup,
+	"customersignupenabled":     settingSelfSignup,
+	"allowsignup":               settingSelfSignup,
+	"domainrestricted":          settingDomainRestricted,
+	"domainrestrictionenabled":  settingDomainRestricted,
+	"signuprestrictedtodomains": settingDomainRestricted,
+	"alloweddomains":            settingDomains,
+	"restricteddomains":         settingDomains,
+	"signupdomains":             settingDomains,
+	"approveddomains":           settingDomains,
+	"anonymousaccess":           settingAnonymousAccess,
+	"anonymousaccessenabled":    settingAnonymousAccess,
+	"anonymousmodeenabled":      settingAnonymousAccess,
+	"publicaccessenabled":       settingAnonymousAccess,
+	"unlicensedaccessenabled":   settingAnonymousAccess,
+}
+
+// SignupProbe is what the public portal configuration reveals about
+// self-signup. Unset answers could not be determined.
+type SignupProbe struct {
+	SelfSignup      *bool
+	Domains         []string
+	DomainsLimited  *bool
+	AnonymousAccess *bool
+	Evidence        []string
+}
+
+// probeSignup inspects the portal's public configuration and settings
+// without submitting an email. The models are read with the session, if
+// any; anonymous access is always checked without one.
+func probeSignup(baseURL, cookie string, timeout int) (*SignupProbe, error) {
+	client := newClient(baseURL, cookie, time.Duration(timeout)*time.Second)
+	anonClient := newClient(baseURL, "", time.Duration(timeout)*time.Second)
+	probe := &SignupProbe{}
+
+	payload := map[string]interface{}{
+		"models":  portalModels,
+		"options": map[string]interface{}{},
+	}
+
+	resp, err := client.post("/rest/servicedesk/1/customer/models", payload)
+	if err != nil {
+		return nil, fmt.Errorf("get portal models: %w", err)
+	}
+
+	body, err := readBody(resp)
+	if err != nil {
+		return nil, fmt.Errorf("read portal models: %w", err)
+	}
+	probe.Evidence = append(probe.Evidence, fmt.Sprintf("POST /rest/servicedesk/1/customer/models -> %d", resp.StatusCode))
+
+	if resp.StatusCode == 200 {
+		var models interface{}
+		if err := json.Unmarshal(body, &models); err != nil {
+			return nil, fmt.Errorf("parse portal models: %w", err)
+		}
+		walkJSON("", models, probe.inspect)
+	}
+
+	resp, err = anonClient.get("/rest/servicedeskapi/servicedesk")
+	if err != nil {
+		return nil, fmt.Errorf("get service desks: %w", err)
+	}
+
+	body, err = readBody(resp)
+	if err != nil {
+		return nil, fmt.Errorf("read service desks: %w", err)
+	}
+
+	switch resp.StatusCode {
+	case 200:
+		desks := countItems(body)
+		probe.Evidence = append(probe.Evidence, fmt.Sprintf("GET /rest/servicedeskapi/servicedesk -> 200, %d desk(s)", desks))
+		probe.AnonymousAccess = boolPtr(desks > 0)
+	case 401, 403:
+		probe.Evidence = append(probe.Evidence, fmt.Sprintf("GET /rest/servicedeskapi/servicedesk -> %d", resp.StatusCode))
+		if probe.AnonymousAccess == nil {
+			probe.AnonymousAccess = boolPtr(false)
+		}
+	default:
+		probe.Evidence = append(probe.Evidence, fmt.Sprintf("GET /rest/servicedeskapi/servicedesk -> %d", resp.StatusCode))
+	}
+
+	sort.Strings(probe.Domains)
+	return probe, nil
+}
+
+// inspect records the setting a known portal model field reveals
+func (p *SignupProbe) inspect(path string, value interface{}) {
+	setting, ok := signupFields[strings.ToLower(path[strings.LastIndex(path, ".")+1:])]
+	if !ok {
+		return
+	}
+
+	switch v := value.(type) {
+	case bool:
+		switch setting {
+		case settingSelfSignup:
+			p.SelfSignup = boolPtr(v)
+		case settingDomainRestricted:
+			p.DomainsLimited = boolPtr(v)
+		case settingAnonymousAccess:
+			p.AnonymousAccess = boolPtr(v)
+		default:
+			return
+		}
+	case []interface{}:
+		if setting != settingDomains {
+			return
+		}
+		for _, item := range v {
+			if domain, ok := item.(string); ok && domain != "" {
+				p.Domains = appendUnique(p.Domains, domain)
+			}
+		}
+		p.DomainsLimited = boolPtr(len(p.Domains) > 0)
+	default:
+		return
+	}
+
+	p.Evidence = append(p.Evidence, fmt.Sprintf("%s = %v", path, value))
+}
+
+// walkJSON calls fn for every leaf and list of a decoded JSON value, in
+// key order so that repeated runs report the same evidence
+func walkJSON(path string, value interface{}, fn func(path string, value interface{})) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			childPath := key
+			if path != "" {
+				childPath = path + "." + key
+			}
+			walkJSON(childPath, v[key], fn)
+		}
+	case []interface{}:
+		fn(path, v)
+		for _, child := range v {
+			if _, ok := child.(map[string]interface{}); ok {
+				walkJSON(path, child, fn)
+			}
+		}
+	default:
+		fn(path, v)
+	}
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+func printSignupProbe(probe *SignupProbe) {
+	fmt.Println("\nSignup probe:")
+	fmt.Println(strings.Repeat("-", 80))
+
+	fmt.Println("Self-signup:             " + answer(probe.SelfSignup, "enabled", "disabled"))
+
+	domains := answer(probe.DomainsLimited, "restricted", "any domain")
+	if len(probe.Domains) > 0 {
+		domains += " (" + strings.Join(probe.Domains, ", ") + ")"
+	}
+	fmt.Println("Domain restriction:      " + domains)
+
+	fmt.Println("Anonymous portal access: " + answer(probe.AnonymousAccess, "allowed", "denied"))
+
+	fmt.Println("\nEvidence:")
+	for _, evidence := range probe.Evidence {
+		fmt.Println("  " + evidence)
+	}
+}
+
+func answer(value *bool, yes, no string) string {
+	switch {
+	case value == nil:
+		return "unknown"
+	case *value:
+		return yes
+	default:
+		return no
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright 2025 İrem Kuyucu
+// Copyright 2025 Laurynas Četyrkinas
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+type Project struct {
+	ID   string `json:"id"`
+	Key  string `json:"key"`
+	Name string `json:"name"`
+}
+
+type ProjectSearchResponse struct {
+	Values []Project `json:"values"`
+	IsLast bool      `json:"isLast"`
+}
+
+// resolveProjects returns the project keys to check. "all" discovers them
+// from the service desks and the project search visible to the session.
+func resolveProjects(client *Client, projects string) []string {
+	if !strings.EqualFold(projects, "all") {
+		return splitList(projects)
+	}
+
+	seen := make(map[string]bool)
+	var keys []string
+	add := func(key string) {
+		if key != "" && !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+
+	desks, err := getServiceDesks(client)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	for _, desk := range desks {
+		add(desk.ProjectKey)
+	}
+
+	found, err := searchProjects(client)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	for _, project := range found {
+		add(project.Key)
+	}
+
+	sort.Strings(keys)
+	fmt.Printf("Discovered %d project(s)\n", len(keys))
+	return keys
+}
+
+// searchProjects pages through the projects the session can browse
+func searchProjects(client *Client) ([]Project, error) {
+	var projects []Project
+
+	for {
+		resp, err := client.get(fmt.Sprintf("/rest/api/3/project/search?maxResults=100&startAt=%d", len(projects)))
+		if err != nil {
+			return projects, fmt.Errorf("search projects: %w", err)
+		}
+
+		if resp.StatusCode != 200 {
+			readBody(resp)
+			return projects, fmt.Errorf("search projects: unexpected status %d", resp.StatusCode)
+		}
+
+		var page ProjectSearchResponse
+		if err := unmarshalJSON(resp, &page); err != nil {
+			return projects, fmt.Errorf("parse projects: %w", err)
+		}
+		projects = append(projects, page.Values...)
+
+		if page.IsLast || len(page.Values) == 0 {
+			return projects, nil
+		}
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright 2025 İrem Kuyucu
+// Copyright 2025 Laurynas Četyrkinas
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import "sort"
+
+const (
+	globalPermissionsSetting  = "Settings > System > Global permissions"
+	projectPermissionsSetting = "Project settings > Permissions (permission scheme)"
+)
+
+// permissionRisk describes what an external customer could do with a
+// permission and which Jira setting controls it
+type permissionRisk struct {
+	severity    severity
+	impact      string
+	remediation string
+}
+
+var permissionRisks = map[string]permissionRisk{
+	// Global permissions
+	"ADMINISTER":                        {severityCritical, "Administer Jira: users, groups, schemes and global settings", globalPermissionsSetting + ": remove customer groups from Jira Administrators"},
+	"SYSTEM_ADMIN":                      {severityCritical, "System administration including mail servers, integrations and backups", globalPermissionsSetting + ": restrict Jira System Administrators"},
+	"USER_PICKER":                       {severityHigh, "Enumerate the names, emails and account IDs of every user", globalPermissionsSetting + ": Browse users and groups"},
+	"BROWSE_USERS":                      {severityHigh, "Enumerate the names, emails and account IDs of every user", globalPermissionsSetting + ": Browse users and groups"},
+	"BULK_CHANGE":                       {severityMedium, "Edit, move or delete many visible issues at once", globalPermissionsSetting + ": Make bulk changes"},
+	"CREATE_SHARED_OBJECTS":             {severityMedium, "Share filters and dashboards with other users", globalPermissionsSetting + ": Share dashboards and filters"},
+	"MANAGE_GROUP_FILTER_SUBSCRIPTIONS": {severityMedium, "Email filter results to whole groups", globalPermissionsSetting + ": Manage group filter subscriptions"},
+
+	// Project permissions
+	"SERVICEDESK_AGENT":        {severityCritical, "Act as an agent: read and answer every request of the desk", "Project settings > People: remove customers from the Service Desk Team role"},
+	"ADMINISTER_PROJECTS":      {severityCritical, "Change project settings, roles, components and versions", projectPermissionsSetting + ": Administer Projects"},
+	"DELETE_ISSUES":            {severityCritical, "Delete issues together with their history", projectPermissionsSetting + ": Delete Issues"},
+	"BROWSE_PROJECTS":          {severityHigh, "Read every issue of the project, including other customers' requests", projectPermissionsSetting + ": Browse Projects must not include Anyone or Any logged in user"},
+	"EDIT_ISSUES":              {severityHigh, "Edit issues raised by other users", projectPermissionsSetting + ": Edit Issues"},
+	"MOVE_ISSUES":              {severityHigh, "Move issues to other projects", projectPermissionsSetting + ": Move Issues"},
+	"SET_ISSUE_SECURITY":       {severityHigh, "Lower the security level of restricted issues", projectPermissionsSetting + ": Set Issue Security"},
+	"EDIT_ALL_COMMENTS":        {severityHigh, "Alter the comments of other users", projectPermissionsSetting + ": Edit All Comments"},
+	"DELETE_ALL_COMMENTS":      {severityHigh, "Remove the comments of other users", projectPermissionsSetting + ": Delete All Comments"},
+	"DELETE_ALL_ATTACHMENTS":   {severityHigh, "Remove the attachments of other users", projectPermissionsSetting + ": Delete All Attachments"},
+	"CREATE_ISSUES":            {severityMedium, "Create issues directly, bypassing the portal request types", projectPermissionsSetting + ": Create Issues"},
+	"TRANSITION_ISSUES":        {severityMedium, "Move issues through the workflow", projectPermissionsSetting + ": Transition Issues"},
+	"RESOLVE_ISSUES":           {severityMedium, "Resolve issues of other users", projectPermissionsSetting + ": Resolve Issues"},
+	"CLOSE_ISSUES":             {severityMedium, "Close issues of other users", projectPermissionsSetting + ": Close Issues"},
+	"MODIFY_REPORTER":          {severityMedium, "Raise issues in the name of other users", projectPermissionsSetting + ": Modify Reporter"},
+	"MANAGE_WATCHERS":          {severityMedium, "Add or remove the watchers of issues", projectPermissionsSetting + ": Manage Watchers"},
+	"EDIT_ALL_WORKLOGS":        {severityMedium, "Alter the work logs of other users", projectPermissionsSetting + ": Edit All Worklogs"},
+	"DELETE_ALL_WORKLOGS":      {severityMedium, "Remove the work logs of other users", projectPermissionsSetting + ": Delete All Worklogs"},
+	"VIEW_DEV_TOOLS":           {severityMedium, "See linked commits, branches and pull requests", projectPermissionsSetting + ": View Development Tools"},
+	"ASSIGN_ISSUES":            {severityLow, "Assign issues to users", projectPermissionsSetting + ": Assign Issues"},
+	"ASSIGNABLE_USER":          {severityLow, "Have issues assigned to the account", projectPermissionsSetting + ": Assignable User"},
+	"ADD_COMMENTS":             {severityLow, "Comment on visible issues", projectPermissionsSetting + ": Add Comments"},
+	"CREATE_ATTACHMENTS":       {severityLow, "Upload files to visible issues", projectPermissionsSetting + ": Create Attachments"},
+	"LINK_ISSUES":              {severityLow, "Link visible issues together", projectPermissionsSetting + ": Link Issues"},
+	"SCHEDULE_ISSUES":          {severityLow, "Set due dates of issues", projectPermissionsSetting + ": Schedule Issues"},
+	"WORK_ON_ISSUES":           {severityLow, "Log work on visible issues", projectPermissionsSetting + ": Work On Issues"},
+	"VIEW_VOTERS_AND_WATCHERS": {severityLow, "See who votes on and watches issues", projectPermissionsSetting + ": View Voters and Watchers"},
+	"VIEW_READONLY_WORKFLOW":   {severityInfo, "View project workflows", projectPermissionsSetting + ": View Read-Only Workflow"},
+}
+
+// riskOf returns the risk of a permission. Unknown keys are rated low and
+// pointed at the scheme that matches their type.
+func riskOf(perm MyPermission) permissionRisk {
+	if risk, ok := permissionRisks[perm.Key]; ok {
+		return risk
+	}
+
+	remediation := projectPermissionsSetting
+	if perm.Type == "GLOBAL" {
+		remediation = globalPermissionsSetting
+	}
+	return permissionRisk{severity: severityLow, remediation: remediation}
+}
+
+// sortPermissionKeys orders keys with granted permissions first, then by
+// risk and key
+func sortPermissionKeys(keys []string, granted func(key string) bool, perm func(key string) MyPermission) {
+	sort.SliceStable(keys, func(i, j int) bool {
+		gi, gj := granted(keys[i]), granted(keys[j])
+		if gi != gj {
+			return gi
+		}
+		si, sj := riskOf(perm(keys[i])).severity, riskOf(perm(keys[j])).severity
+		if si != sj {
+			return si > sj
+		}
+		return keys[i] < keys[j]
+	})
+}
@@ -0,0 +1,306 @@
+// Copyright 2025 İrem Kuyucu
+// Copyright 2025 Laurynas Četyrkinas
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type severity int
+
+const (
+	severityInfo severity = iota
+	severityLow
+	severityMedium
+	severityHigh
+	severityCritical
+)
+
+var severityNames = map[severity]string{
+	severityInfo:     "info",
+	severityLow:      "low",
+	severityMedium:   "medium",
+	severityHigh:     "high",
+	severityCritical: "critical",
+}
+
+func (s severity) String() string {
+	return severityNames[s]
+}
+
+func parseSeverity(name string) (severity, error) {
+	for s, n := range severityNames {
+		if strings.EqualFold(n, name) {
+			return s, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown severity %q", name)
+}
+
+type scanRule struct {
+	name     string
+	severity severity
+	pattern  *regexp.Regexp
+}
+
+type Finding struct {
+	DocumentARI string
+	Title       string
+	Rule        string
+	Severity    severity
+	Source      string
+	Line        int
+	Context     string
+	Match       string
+	Exposure    string
+}
+
+var defaultScanRules = []scanRule{
+	{"private-key", severityCritical, regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----`)},
+	{"aws-access-key", severityCritical, regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"aws-secret-key", severityCritical, regexp.MustCompile(`(?i)aws.{0,20}secret.{0,20}[=:]\s*[A-Za-z0-9/+]{40}\b`)},
+	{"github-token", severityHigh, regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36}\b`)},
+	{"slack-token", severityHigh, regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}`)},
+	{"password", severityHigh, regexp.MustCompile(`(?i)\b(?:password|passwd|pwd|passphrase|kennwort|parola)\b\s*[:=]\s*\S{3,}`)},
+	{"vpn-config", severityHigh, regexp.MustCompile(`(?im)^\s*(?:remote\s+\S+\s+\d{2,5}|auth-user-pass\b|PrivateKey\s*=|PresharedKey\s*=)`)},
+	{"jwt", severityMedium, regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}`)},
+	{"iban", severityMedium, regexp.MustCompile(`\b[A-Z]{2}\d{2}(?: ?[A-Z0-9]{4}){3,7}(?: ?[A-Z0-9]{1,3})?\b`)},
+	{"internal-url", severityLow, regexp.MustCompile(`(?i)\bhttps?://(?:[a-z0-9-]+\.)*(?:internal|intranet|corp|local|lan)\b[^\s"'<>]*|\bhttps?://(?:10|192\.168|172\.(?:1[6-9]|2\d|3[01]))(?:\.\d{1,3}){2,3}[^\s"'<>]*`)},
+	{"email", severityInfo, regexp.MustCompile(`\b[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}\b`)},
+}
+
+// highEntropyPattern finds candidate tokens for the entropy check
+var highEntropyPattern = regexp.MustCompile(`[A-Za-z0-9+/=_-]{24,}`)
+
+const entropyThreshold = 4.5
+
+// loadScanRules reads extra rules from a file with one
+// "name:severity:regex" rule per line
+func loadScanRules(path string) ([]scanRule, error) {
+	lines, err := readLines(path)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := make([]scanRule, 0, len(lines))
+	for i, line := range lines {
+		parts := strings.SplitN(line, ":", 3)
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("rule %d: expected name:severity:regex", i+1)
+		}
+
+		sev, err := parseSeverity(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("rule %d: %w", i+1, err)
+		}
+
+		pattern, err := regexp.Compile(parts[2])
+		if err != nil {
+			return nil, fmt.Errorf("rule %d: %w", i+1, err)
+		}
+
+		rules = append(rules, scanRule{name: parts[0], severity: sev, pattern: pattern})
+	}
+
+	return rules, nil
+}
+
+// scanDocuments runs the rules over every document title and, where content
+// was fetched, its body. Findings are sorted most severe first.
+func scanDocuments(docs []Document, rules []scanRule) []Finding {
+	var findings []Finding
+
+	for _, doc := range docs {
+		findings = append(findings, scanText(doc, "title", doc.Title, rules)...)
+
+		if doc.ContentPath == "" {
+			continue
+		}
+
+		body, err := os.ReadFile(doc.ContentPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: read content of '%s' failed: %v\n", doc.Title, err)
+			continue
+		}
+		findings = append(findings, scanText(doc, "body", string(body), rules)...)
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		return findings[i].Severity > findings[j].Severity
+	})
+
+	return findings
+}
+
+func scanText(doc Document, source, text string, rules []scanRule) []Finding {
+	var findings []Finding
+
+	for lineNo, line := range strings.Split(text, "\n") {
+		var lineFindings []Finding
+		var spans [][]int
+
+		for _, rule := range rules {
+			for _, loc := range rule.pattern.FindAllStringIndex(line, -1) {
+				spans = append(spans, loc)
+				lineFindings = append(lineFindings, newFinding(doc, source, lineNo+1, rule.name, rule.severity, line[loc[0]:loc[1]]))
+			}
+		}
+
+		for _, loc := range highEntropyPattern.FindAllStringIndex(line, -1) {
+			token := line[loc[0]:loc[1]]
+			if shannonEntropy(token) >= entropyThreshold {
+				spans = append(spans, loc)
+				lineFindings = append(lineFindings, newFinding(doc, source, lineNo+1, "high-entropy-string", severityMedium, token))
+			}
+		}
+
+		if len(lineFindings) == 0 {
+			continue
+		}
+
+		// Every finding of the line shares one context with all matches
+		// redacted, so no finding repeats a secret another one caught
+		context := truncateRunes(strings.TrimSpace(redactSpans(line, spans)), 200)
+		for i := range lineFindings {
+			lineFindings[i].Context = context
+		}
+		findings = append(findings, lineFindings...)
+	}
+
+	return findings
+}
+
+func newFinding(doc Document, source string, line int, rule string, sev severity, match string) Finding {
+	// Anything readable without a session is one step more severe
+	if doc.Exposure == exposureAnonymous && sev < severityCritical {
+		sev++
+	}
+
+	return Finding{
+		DocumentARI: doc.ARI,
+		Title:       doc.Title,
+		Rule:        rule,
+		Severity:    sev,
+		Source:      source,
+		Line:        line,
+		Match:       redact(match),
+		Exposure:    doc.Exposure,
+	}
+}
+
+// redactSpans redacts the given byte ranges of text. Overlapping ranges
+// are merged and redacted as one.
+func redactSpans(text string, spans [][]int) string {
+	sort.Slice(spans, func(i, j int) bool {
+		return spans[i][0] < spans[j][0]
+	})
+
+	var b strings.Builder
+	pos := 0
+	for i := 0; i < len(spans); i++ {
+		start, end := spans[i][0], spans[i][1]
+		for i+1 < len(spans) && spans[i+1][0] < end {
+			i++
+			if spans[i][1] > end {
+				end = spans[i][1]
+			}
+		}
+		b.WriteString(text[pos:start])
+		b.WriteString(redact(text[start:end]))
+		pos = end
+	}
+	b.WriteString(text[pos:])
+
+	return b.String()
+}
+
+// truncateRunes shortens s to at most n characters without splitting a
+// multi-byte character
+func truncateRunes(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n]) + "..."
+}
+
+// redact keeps the first four characters of a match so findings can be
+// told apart without repeating the secret
+func redact(match string) string {
+	runes := []rune(match)
+	if len(runes) <= 4 {
+		return strings.Repeat("*", len(runes))
+	}
+	return string(runes[:4]) + strings.Repeat("*", len(runes)-4)
+}
+
+func shannonEntropy(s string) float64 {
+	counts := make(map[rune]int)
+	for _, r := range s {
+		counts[r]++
+	}
+
+	entropy := 0.0
+	length := float64(len([]rune(s)))
+	for _, count := range counts {
+		p := float64(count) / length
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+func writeFindingsToCSV(findings []Finding, outputPath string) error {
+	file, err := os.Create(outputPath)
+	if err != nil {
+		return fmt.Errorf("create CSV file: %w", err)
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	defer writer.Flush()
+
+	if err := writer.Write([]string{"Severity", "Rule", "ARI", "Title", "Source", "Line", "Match", "Context", "Exposure"}); err != nil {
+		return fmt.Errorf("write CSV header: %w", err)
+	}
+
+	for _, f := range findings {
+		row := []string{f.Severity.String(), f.Rule, f.DocumentARI, f.Title, f.Source, strconv.Itoa(f.Line), f.Match, f.Context, f.Exposure}
+		if err := writer.Write(row); err != nil {
+			return fmt.Errorf("write CSV row: %w", err)
+		}
+	}
+
+	fmt.Printf("\nWrote %d findings to %s\n", len(findings), outputPath)
+	return nil
+}
+
+func printFindings(findings []Finding) {
+	fmt.Printf("\n\nSensitive Data Findings (%d):\n", len(findings))
+	fmt.Println(strings.Repeat("-", 100))
+
+	for _, f := range findings {
+		fmt.Printf("\n[%s] %s in %s:%d of %s\n", strings.ToUpper(f.Severity.String()), f.Rule, f.Source, f.Line, f.Title)
+		fmt.Println("  Match: " + f.Match)
+		fmt.Println("  Context: " + f.Context)
+	}
+}
@@ -16,12 +16,56 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 )
 
-func signup(baseURL, email string) error {
-	client := newClient(baseURL, "", 10*time.Second)
+type signupOutcome int
+
+const (
+	signupUnknown signupOutcome = iota
+	signupCreated
+	signupAlreadyRegistered
+	signupDisabled
+	signupDomainRestricted
+	signupCaptchaRequired
+	signupRateLimited
+)
+
+// signupOutcomeInfo describes an outcome, the exit code scripts can branch
+// on and what it means for public signup
+type signupOutcomeInfo struct {
+	name     string
+	exitCode int
+	finding  string
+}
+
+// Exit codes start at 10 to stay clear of 1 (errors) and 2 (flag errors)
+var signupOutcomes = map[signupOutcome]signupOutcomeInfo{
+	signupUnknown:           {"unknown", 1, "Could not classify the response"},
+	signupCreated:           {"account created", 0, "Public signup is enabled, check email"},
+	signupAlreadyRegistered: {"email already registered", 10, "Public signup is enabled, the email already has an account"},
+	signupDisabled:          {"signup disabled", 11, "Public signup is disabled on the portal"},
+	signupDomainRestricted:  {"domain restricted", 12, "Signup is restricted to approved email domains"},
+	signupCaptchaRequired:   {"captcha required", 13, "Public signup is enabled behind a captcha"},
+	signupRateLimited:       {"rate limited", 14, "Inconclusive, signup was rate limited"},
+}
+
+type SignupErrorResponse struct {
+	Message      string `json:"message"`
+	ErrorMessage string `json:"errorMessage"`
+	ReasonKey    string `json:"reasonKey"`
+	Errors       []struct {
+		ErrorMessage string `json:"errorMessage"`
+	} `json:"errors"`
+}
+
+// signup submits the signup form and classifies the response. The detail is
+// the server's message, if it sent one.
+func signup(baseURL, cookie, email string, timeout int) (signupOutcome, string, error) {
+	client := newClient(baseURL, cookie, time.Duration(timeout)*time.Second)
 
 	body := map[string]string{
 		"email":          email,
@@ -30,16 +74,60 @@ func signup(baseURL, email string) error {
 
 	resp, err := client.post("/rest/servicedesk/1/customer/pages/user/signup", body)
 	if err != nil {
-		return err
+		return signupUnknown, "", err
+	}
+
+	data, err := readBody(resp)
+	if err != nil {
+		return signupUnknown, "", fmt.Errorf("read response: %w", err)
 	}
-	defer resp.Body.Close()
 
-	switch resp.StatusCode {
-	case 204:
-		return nil
-	case 403:
-		return fmt.Errorf("signup forbidden (403)")
-	default:
-		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	outcome, detail := classifySignup(resp.StatusCode, data)
+	return outcome, detail, nil
+}
+
+// classifySignup maps a signup response to an outcome by status code and
+// the wording of the error message
+func classifySignup(status int, body []byte) (signupOutcome, string) {
+	detail := signupDetail(body)
+	text := strings.ToLower(string(body))
+
+	switch {
+	case status == 200 || status == 204:
+		return signupCreated, detail
+	case status == 429 || strings.Contains(text, "rate limit") || strings.Contains(text, "too many"):
+		return signupRateLimited, detail
+	case strings.Contains(text, "captcha"):
+		return signupCaptchaRequired, detail
+	case strings.Contains(text, "already") || strings.Contains(text, "exists") || strings.Contains(text, "in use"):
+		return signupAlreadyRegistered, detail
+	case strings.Contains(text, "domain"):
+		return signupDomainRestricted, detail
+	case status == 403 || strings.Contains(text, "disabled") || strings.Contains(text, "not allowed") || strings.Contains(text, "not permitted"):
+		return signupDisabled, detail
+	}
+
+	if detail == "" {
+		detail = fmt.Sprintf("unexpected status code: %d", status)
+	}
+	return signupUnknown, detail
+}
+
+func signupDetail(body []byte) string {
+	var response SignupErrorResponse
+	if json.Unmarshal(body, &response) != nil {
+		return truncateRunes(strings.TrimSpace(string(body)), 200)
+	}
+
+	for _, e := range response.Errors {
+		if e.ErrorMessage != "" {
+			return e.ErrorMessage
+		}
+	}
+	for _, message := range []string{response.ErrorMessage, response.Message, response.ReasonKey} {
+		if message != "" {
+			return message
+		}
 	}
+	return ""
 }
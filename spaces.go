@@ -0,0 +1,166 @@
+// Copyright 2025 İrem Kuyucu
+// Copyright 2025 Laurynas Četyrkinas
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const spaceSampleTitles = 3
+
+type Space struct {
+	Name      string
+	ARI       string
+	SpaceID   string
+	Documents []Document
+	FirstSeen time.Time
+	LastSeen  time.Time
+}
+
+// spaceFilter restricts documents to, or excludes them from, containers
+// matched by name, container ARI or space ID
+type spaceFilter struct {
+	include []string
+	exclude []string
+}
+
+func newSpaceFilter(include, exclude string) spaceFilter {
+	return spaceFilter{include: splitList(include), exclude: splitList(exclude)}
+}
+
+func (f spaceFilter) allows(doc Document) bool {
+	if len(f.include) > 0 && !matchesSpace(doc, f.include) {
+		return false
+	}
+	return !matchesSpace(doc, f.exclude)
+}
+
+func matchesSpace(doc Document, spaces []string) bool {
+	for _, space := range spaces {
+		if strings.EqualFold(space, doc.ContainerName) || space == doc.ContainerARI || (doc.SpaceID != "" && space == doc.SpaceID) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitList splits a comma-separated flag value, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// groupBySpace aggregates documents by container, largest spaces first
+func groupBySpace(docs []Document) []Space {
+	index := make(map[string]int)
+	var spaces []Space
+
+	for _, doc := range docs {
+		// Knowledge base articles carry a space key but no container ARI
+		key := doc.ContainerARI
+		if key == "" {
+			key = doc.ContainerName
+		}
+
+		i, ok := index[key]
+		if !ok {
+			i = len(spaces)
+			index[key] = i
+			spaces = append(spaces, Space{Name: doc.ContainerName, ARI: doc.ContainerARI, SpaceID: doc.SpaceID, FirstSeen: doc.FirstSeen})
+		}
+
+		space := &spaces[i]
+		space.Documents = append(space.Documents, doc)
+		if doc.FirstSeen.Before(space.FirstSeen) {
+			space.FirstSeen = doc.FirstSeen
+		}
+		if doc.LastSeen.After(space.LastSeen) {
+			space.LastSeen = doc.LastSeen
+		}
+	}
+
+	sort.SliceStable(spaces, func(i, j int) bool {
+		return len(spaces[i].Documents) > len(spaces[j].Documents)
+	})
+
+	return spaces
+}
+
+func (s Space) sampleTitles() []string {
+	var titles []string
+	for i := 0; i < len(s.Documents) && i < spaceSampleTitles; i++ {
+		titles = append(titles, s.Documents[i].Title)
+	}
+	return titles
+}
+
+func writeSpacesToCSV(spaces []Space, outputPath string) error {
+	file, err := os.Create(outputPath)
+	if err != nil {
+		return fmt.Errorf("create CSV file: %w", err)
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	defer writer.Flush()
+
+	if err := writer.Write([]string{"Container", "Container ARI", "Space ID", "Documents", "Sample Titles", "First Seen", "Last Seen"}); err != nil {
+		return fmt.Errorf("write CSV header: %w", err)
+	}
+
+	for _, space := range spaces {
+		row := []string{
+			space.Name,
+			space.ARI,
+			space.SpaceID,
+			strconv.Itoa(len(space.Documents)),
+			strings.Join(space.sampleTitles(), "; "),
+			space.FirstSeen.Format(time.RFC3339),
+			space.LastSeen.Format(time.RFC3339),
+		}
+		if err := writer.Write(row); err != nil {
+			return fmt.Errorf("write CSV row: %w", err)
+		}
+	}
+
+	fmt.Printf("\nWrote %d spaces to %s\n", len(spaces), outputPath)
+	return nil
+}
+
+func printSpaces(spaces []Space) {
+	fmt.Printf("\n\nExposed Spaces (%d):\n", len(spaces))
+	fmt.Println(strings.Repeat("-", 100))
+
+	for _, space := range spaces {
+		fmt.Printf("\n%s exposes %d page(s)\n", space.Name, len(space.Documents))
+		fmt.Println("  ARI: " + space.ARI)
+		fmt.Printf("  Seen: %s - %s\n", space.FirstSeen.Format(time.RFC3339), space.LastSeen.Format(time.RFC3339))
+		for _, doc := range space.Documents {
+			fmt.Println("  - " + doc.Title + " (" + doc.AbsoluteURL + ")")
+		}
+	}
+}
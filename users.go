@@ -19,10 +19,13 @@ import (
 	"context"
 	"encoding/csv"
 	"fmt"
+	"net/url"
 	"os"
+	"sort"
 	"strings"
 	"sync"
 	"time"
+	"unicode"
 )
 
 type ServiceDeskResponse struct {
@@ -42,6 +45,7 @@ type User struct {
 	EmailAddress string `json:"emailAddress"`
 	DisplayName  string `json:"displayName"`
 	Avatar       string `json:"avatar"`
+	Exposure     string `json:"-"`
 }
 
 const defaultAvatar = "/default-avatar.png"
@@ -50,6 +54,7 @@ type userSearchTask struct {
 	deskID string
 	query  string
 	depth  int
+	found  []string // account IDs the session saw, recorded once the task completed
 }
 
 type userSearchResult struct {
@@ -60,7 +65,7 @@ type userSearchResult struct {
 	err    error
 }
 
-func enumerateUsers(baseURL, cookie string, maxUsers int, deskID string, customQuery string, alphabet1, alphabet2 string, selfAccountID string, outputPath string, workers, timeout int) error {
+func enumerateUsers(baseURL, cookie string, maxUsers int, deskID string, customQuery string, seeds []string, tokenSeeding, scopeCheck bool, alphabet1, alphabet2 string, selfAccountID string, outputPath string, workers, timeout int, limits *budget, compareAnonymous bool, compareSample int, estimateOutput string) error {
 	client := newClient(baseURL, cookie, time.Duration(timeout)*time.Second)
 
 	var desks []ServiceDesk
@@ -69,46 +74,86 @@ func enumerateUsers(baseURL, cookie string, maxUsers int, deskID string, customQ
 	if targetingSingleDesk {
 		desks = []ServiceDesk{{ID: deskID}}
 	} else {
-		resp, err := client.get("/rest/servicedeskapi/servicedesk")
+		var err error
+		desks, err = getServiceDesks(client)
 		if err != nil {
-			return fmt.Errorf("get service desks: %w", err)
+			return err
 		}
+		fmt.Printf("\nFound %d service desk(s)\n", len(desks))
+	}
 
-		var desksResp ServiceDeskResponse
-		if err := unmarshalJSON(resp, &desksResp); err != nil {
-			return fmt.Errorf("parse service desks: %w", err)
+	// Desks whose user search returns the same tenant-wide population are
+	// enumerated once and the results attributed to the whole group
+	deskGroups := make([][]ServiceDesk, 0, len(desks))
+	if scopeCheck && customQuery == "" && len(desks) > 1 {
+		deskGroups = groupDesksByScope(client, desks, limits)
+	} else {
+		for _, desk := range desks {
+			deskGroups = append(deskGroups, []ServiceDesk{desk})
 		}
-
-		desks = desksResp.Values
-		fmt.Printf("\nFound %d service desk(s)\n", len(desks))
 	}
 
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+	rootCtx, stop := limits.withDeadline(context.Background())
+	defer stop()
 
-	interruptedChan := setupSignalHandler(cancel)
+	interruptedChan := setupSignalHandler(stop)
 
 	userMap := make(map[string]User)
+	userDesks := make(map[string][]string)
+	var issuedTasks []userSearchTask
+	var estimates []DeskEstimate
 
-	for _, desk := range desks {
-		select {
-		case <-interruptedChan:
+	for _, group := range deskGroups {
+		if rootCtx.Err() != nil || limits.exhausted() {
 			break
-		default:
 		}
 
+		desk := group[0]
 		if desk.ProjectName != "" {
 			fmt.Println("\nService Desk: " + desk.ProjectName + " (" + desk.ProjectKey + ") [ID: " + desk.ID + "]")
 		} else {
 			fmt.Println("\nService Desk: [ID: " + desk.ID + "]")
 		}
 
+		groupIDs := make([]string, 0, len(group))
+		for _, member := range group {
+			groupIDs = append(groupIDs, member.ID)
+		}
+		if len(group) > 1 {
+			fmt.Printf("  Tenant-wide user search, results shared with desk(s): %s\n", strings.Join(groupIDs[1:], ", "))
+		}
+
+		ctx, cancel := context.WithCancel(rootCtx)
+
 		totalFetched := 0
 		capped := false
+		completed := false
+		tracker := newCoverageTracker(selfAccountID)
 		seenAccountIDs := make(map[string]bool)
 		searchCount := 0
 
-		taskQueue := make(chan userSearchTask, 5000)
+		// Seeds replace the empty initial query; prefix expansion only kicks
+		// in for seeds that come back saturated
+		queries := []string{customQuery}
+		if customQuery == "" && len(seeds) > 0 {
+			queries = seeds
+		}
+
+		initialTasks := make([]userSearchTask, 0, len(queries))
+		for _, query := range queries {
+			if !limits.spend() {
+				break
+			}
+			initialTasks = append(initialTasks, userSearchTask{deskID: desk.ID, query: query, depth: 0})
+		}
+
+		// Queries already issued on this desk, so derived tokens are not repeated
+		queuedQueries := make(map[string]bool)
+		for _, task := range initialTasks {
+			queuedQueries[strings.ToLower(task.query)] = true
+		}
+
+		taskQueue := make(chan userSearchTask, 5000+len(initialTasks))
 		results := make(chan userSearchResult, workers*2)
 
 		// Worker pool
@@ -127,14 +172,7 @@ func enumerateUsers(baseURL, cookie string, maxUsers int, deskID string, customQ
 							return
 						}
 
-						var url string
-						if task.query == "" {
-							url = fmt.Sprintf("/rest/servicedesk/1/customer/portal/%s/user-search/proforma", task.deskID)
-						} else {
-							url = fmt.Sprintf("/rest/servicedesk/1/customer/portal/%s/user-search/proforma?query=%s", task.deskID, task.query)
-						}
-
-						resp, err := client.get(url)
+						users, err := searchUsers(client, task.deskID, task.query)
 						if err != nil {
 							select {
 							case results <- userSearchResult{deskID: task.deskID, query: task.query, depth: task.depth, err: err}:
@@ -144,16 +182,6 @@ func enumerateUsers(baseURL, cookie string, maxUsers int, deskID string, customQ
 							continue
 						}
 
-						var users []User
-						if err := unmarshalJSON(resp, &users); err != nil {
-							select {
-							case results <- userSearchResult{deskID: task.deskID, query: task.query, depth: task.depth, err: err}:
-							case <-ctx.Done():
-								return
-							}
-							continue
-						}
-
 						select {
 						case results <- userSearchResult{deskID: task.deskID, query: task.query, depth: task.depth, users: users}:
 						case <-ctx.Done():
@@ -169,7 +197,25 @@ func enumerateUsers(baseURL, cookie string, maxUsers int, deskID string, customQ
 		processorWg.Add(1)
 		go func() {
 			defer processorWg.Done()
-			pendingTasks := 1 // Start with 1 (initial query)
+			pendingTasks := len(initialTasks)
+
+			// Tasks created here can outgrow the queue, so they wait in the
+			// backlog and are fed in as slots free up. Blocking on a full
+			// queue would stall the workers, which wait for this goroutine
+			// to take results.
+			var backlog []userSearchTask
+			feedBacklog := func() {
+				for len(backlog) > 0 {
+					select {
+					case <-ctx.Done():
+						return
+					case taskQueue <- backlog[0]:
+						backlog = backlog[1:]
+					default:
+						return
+					}
+				}
+			}
 
 			for result := range results {
 				pendingTasks-- // This task completed
@@ -177,14 +223,24 @@ func enumerateUsers(baseURL, cookie string, maxUsers int, deskID string, customQ
 
 				if result.err != nil {
 					fmt.Fprintf(os.Stderr, "Warning: search for '%s' failed: %v\n", result.query, result.err)
+					tracker.fail()
 					if pendingTasks == 0 {
+						completed = true
 						cancel()
 						return
 					}
+					feedBacklog()
 					continue
 				}
 
+				issued := userSearchTask{deskID: result.deskID, query: result.query, depth: result.depth}
+				for _, user := range result.users {
+					issued.found = append(issued.found, user.AccountID)
+				}
+				issuedTasks = append(issuedTasks, issued)
+
 				newUsersThisBatch := 0
+				var newTokens []string
 				for _, user := range result.users {
 					if seenAccountIDs[user.AccountID] || user.AccountID == selfAccountID {
 						continue
@@ -202,6 +258,16 @@ func enumerateUsers(baseURL, cookie string, maxUsers int, deskID string, customQ
 					if _, exists := userMap[user.AccountID]; !exists {
 						userMap[user.AccountID] = user
 					}
+					userDesks[user.AccountID] = append(userDesks[user.AccountID], groupIDs...)
+
+					if tokenSeeding {
+						for _, token := range userTokens(user) {
+							if !queuedQueries[token] {
+								queuedQueries[token] = true
+								newTokens = append(newTokens, token)
+							}
+						}
+					}
 				}
 
 				// Detect truncation and expand search
@@ -213,6 +279,7 @@ func enumerateUsers(baseURL, cookie string, maxUsers int, deskID string, customQ
 				if capped {
 					status = "⊗"
 				}
+				tracker.record(result.query, result.users, truncated)
 
 				queryDisplay := result.query
 				if queryDisplay == "" {
@@ -227,32 +294,55 @@ func enumerateUsers(baseURL, cookie string, maxUsers int, deskID string, customQ
 				}
 				fmt.Printf(" | Pending: %d\n", pendingTasks)
 
-				if truncated && customQuery == "" && !capped {
+				expanded := false
+				if truncated && customQuery == "" && !capped && limits.allowDepth(result.depth+1) {
 					select {
 					case <-ctx.Done():
+						tracker.skipExpansion()
 						return
 					default:
 						if maxUsers == 0 || totalFetched < maxUsers {
+							expanded = true
 							alphabet := alphabet2
 							if result.depth == 0 {
 								alphabet = alphabet1
 							}
 
 							for _, char := range alphabet {
+								if !limits.spend() {
+									expanded = false
+									break
+								}
+
 								newTask := userSearchTask{deskID: result.deskID, query: result.query + string(char), depth: result.depth + 1}
+								queuedQueries[strings.ToLower(newTask.query)] = true
 								pendingTasks++
-
-								select {
-								case <-ctx.Done():
-									return
-								case taskQueue <- newTask:
-								}
+								backlog = append(backlog, newTask)
 							}
 						}
 					}
 				}
 
+				if truncated && !expanded {
+					tracker.skipExpansion()
+				}
+
+				// Queue name tokens of new users as secondary seeds
+				if len(newTokens) > 0 && customQuery == "" && !capped {
+					for _, token := range newTokens {
+						if !limits.spend() {
+							break
+						}
+						pendingTasks++
+						backlog = append(backlog, userSearchTask{deskID: result.deskID, query: token, depth: 0})
+					}
+				}
+				feedBacklog()
+
 				// Check if we're done
+				if pendingTasks == 0 {
+					completed = true
+				}
 				if pendingTasks == 0 || capped {
 					cancel()
 					return
@@ -260,11 +350,9 @@ func enumerateUsers(baseURL, cookie string, maxUsers int, deskID string, customQ
 			}
 		}()
 
-		// Start with initial query
-		if customQuery != "" {
-			taskQueue <- userSearchTask{deskID: desk.ID, query: customQuery, depth: 0}
-		} else {
-			taskQueue <- userSearchTask{deskID: desk.ID, query: "", depth: 0}
+		// Start with initial queries
+		for _, task := range initialTasks {
+			taskQueue <- task
 		}
 
 		// Wait for completion or interruption
@@ -276,12 +364,23 @@ func enumerateUsers(baseURL, cookie string, maxUsers int, deskID string, customQ
 		wg.Wait()
 		close(results)
 		processorWg.Wait()
+		cancel()
 
 		statusMsg := ""
 		if capped {
 			statusMsg = fmt.Sprintf(" [CAPPED at max=%d]", maxUsers)
 		}
 		fmt.Printf("  Found %d user(s) for this desk%s\n", totalFetched, statusMsg)
+		summary := tracker.summary(desk.ID, totalFetched, completed)
+		estimates = append(estimates, summary)
+		fmt.Printf("  Queries: %d | Saturated: %d | Unexpanded: %d | Failed: %d\n", summary.Queries, summary.Saturated, summary.Unexpanded, summary.Failed)
+		fmt.Println("  Population: " + summary.Population.String())
+	}
+
+	if estimateOutput != "" {
+		if err := writeEstimatesToCSV(estimates, estimateOutput); err != nil {
+			return err
+		}
 	}
 
 	select {
@@ -290,20 +389,164 @@ func enumerateUsers(baseURL, cookie string, maxUsers int, deskID string, customQ
 	default:
 	}
 
+	if reason := limits.stopReason(rootCtx); reason != "" {
+		fmt.Printf("\n*** Stopped early: %s ***\n", reason)
+	}
+
 	if len(userMap) == 0 {
 		fmt.Println("\nNo users found")
 		return nil
 	}
 
+	if compareAnonymous {
+		compareUsersAnonymous(baseURL, time.Duration(timeout)*time.Second, issuedTasks, compareSample, userMap, workers, limits)
+	}
+
 	if outputPath != "" {
-		return writeUsersToCSV(userMap, outputPath)
+		return writeUsersToCSV(userMap, userDesks, outputPath)
 	}
 
-	printUsers(userMap)
+	printUsers(userMap, userDesks)
 	return nil
 }
 
-func writeUsersToCSV(userMap map[string]User, outputPath string) error {
+// scopeSampleQueries are searched on every desk to fingerprint its user population
+var scopeSampleQueries = []string{"", "a", "e", "m"}
+
+// groupDesksByScope groups desks whose sample searches return identical
+// results. Desks that fail, return nothing or no longer fit in the request
+// budget are kept on their own.
+func groupDesksByScope(client *Client, desks []ServiceDesk, limits *budget) [][]ServiceDesk {
+	fmt.Printf("Comparing %d sample queries across desks to detect tenant-wide user search\n", len(scopeSampleQueries))
+
+	var groups [][]ServiceDesk
+	groupIndex := make(map[string]int)
+
+	for _, desk := range desks {
+		if !limits.spendN(len(scopeSampleQueries)) {
+			groups = append(groups, []ServiceDesk{desk})
+			continue
+		}
+
+		fingerprint, err := deskFingerprint(client, desk.ID)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: scope check for desk %s failed: %v\n", desk.ID, err)
+		}
+		if err != nil || fingerprint == "" {
+			groups = append(groups, []ServiceDesk{desk})
+			continue
+		}
+
+		if i, ok := groupIndex[fingerprint]; ok {
+			groups[i] = append(groups[i], desk)
+			continue
+		}
+
+		groupIndex[fingerprint] = len(groups)
+		groups = append(groups, []ServiceDesk{desk})
+	}
+
+	if len(groups) < len(desks) {
+		fmt.Printf("Detected %d distinct user population(s) across %d desk(s)\n", len(groups), len(desks))
+	} else {
+		fmt.Println("User search is desk-scoped")
+	}
+
+	return groups
+}
+
+// deskFingerprint returns the sorted account IDs of all sample searches on a
+// desk, or an empty string if none of them returned any users
+func deskFingerprint(client *Client, deskID string) (string, error) {
+	var parts []string
+	found := false
+
+	for _, query := range scopeSampleQueries {
+		users, err := searchUsers(client, deskID, query)
+		if err != nil {
+			return "", err
+		}
+
+		ids := make([]string, 0, len(users))
+		for _, user := range users {
+			ids = append(ids, user.AccountID)
+		}
+		sort.Strings(ids)
+
+		if len(ids) > 0 {
+			found = true
+		}
+		parts = append(parts, strings.Join(ids, ","))
+	}
+
+	if !found {
+		return "", nil
+	}
+
+	return strings.Join(parts, "|"), nil
+}
+
+// userTokens splits a user's display name and email local part into
+// lowercase words, since user search matches on word prefixes
+func userTokens(user User) []string {
+	text := user.DisplayName
+	if at := strings.Index(user.EmailAddress, "@"); at > 0 {
+		text += " " + user.EmailAddress[:at]
+	}
+
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	tokens := make([]string, 0, len(fields))
+	for _, field := range fields {
+		if len([]rune(field)) >= 2 {
+			tokens = append(tokens, field)
+		}
+	}
+
+	return tokens
+}
+
+func getServiceDesks(client *Client) ([]ServiceDesk, error) {
+	resp, err := client.get("/rest/servicedeskapi/servicedesk")
+	if err != nil {
+		return nil, fmt.Errorf("get service desks: %w", err)
+	}
+
+	var desksResp ServiceDeskResponse
+	if err := unmarshalJSON(resp, &desksResp); err != nil {
+		return nil, fmt.Errorf("parse service desks: %w", err)
+	}
+
+	return desksResp.Values, nil
+}
+
+func searchUsers(client *Client, deskID, query string) ([]User, error) {
+	path := fmt.Sprintf("/rest/servicedesk/1/customer/portal/%s/user-search/proforma", deskID)
+	if query != "" {
+		path += "?query=" + url.QueryEscape(query)
+	}
+
+	resp, err := client.get(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == 401 || resp.StatusCode == 403 {
+		readBody(resp)
+		return nil, fmt.Errorf("%w: status %d", errAccessDenied, resp.StatusCode)
+	}
+
+	var users []User
+	if err := unmarshalJSON(resp, &users); err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+func writeUsersToCSV(userMap map[string]User, userDesks map[string][]string, outputPath string) error {
 	file, err := os.Create(outputPath)
 	if err != nil {
 		return fmt.Errorf("create CSV file: %w", err)
@@ -313,7 +556,7 @@ func writeUsersToCSV(userMap map[string]User, outputPath string) error {
 	writer := csv.NewWriter(file)
 	defer writer.Flush()
 
-	if err := writer.Write([]string{"AccountID", "DisplayName", "Email", "Avatar"}); err != nil {
+	if err := writer.Write([]string{"AccountID", "DisplayName", "Email", "Avatar", "Desks", "Exposure"}); err != nil {
 		return fmt.Errorf("write CSV header: %w", err)
 	}
 
@@ -322,7 +565,7 @@ func writeUsersToCSV(userMap map[string]User, outputPath string) error {
 		if strings.Contains(avatar, defaultAvatar) {
 			avatar = ""
 		}
-		if err := writer.Write([]string{user.AccountID, user.DisplayName, user.EmailAddress, avatar}); err != nil {
+		if err := writer.Write([]string{user.AccountID, user.DisplayName, user.EmailAddress, avatar, strings.Join(userDesks[user.AccountID], ";"), user.Exposure}); err != nil {
 			return fmt.Errorf("write CSV row: %w", err)
 		}
 	}
@@ -331,7 +574,7 @@ func writeUsersToCSV(userMap map[string]User, outputPath string) error {
 	return nil
 }
 
-func printUsers(userMap map[string]User) {
+func printUsers(userMap map[string]User, userDesks map[string][]string) {
 	fmt.Printf("\n\nUnique Users (%d):\n", len(userMap))
 	fmt.Println(strings.Repeat("-", 100))
 
@@ -344,5 +587,11 @@ func printUsers(userMap map[string]User) {
 		if user.Avatar != "" && !strings.Contains(user.Avatar, defaultAvatar) {
 			fmt.Println("  Avatar: " + user.Avatar)
 		}
+		if desks := userDesks[user.AccountID]; len(desks) > 0 {
+			fmt.Println("  Desks: " + strings.Join(desks, ", "))
+		}
+		if user.Exposure != "" {
+			fmt.Println("  Exposure: " + user.Exposure)
+		}
 	}
 }
@@ -0,0 +1,204 @@
+// Copyright 2025 İrem Kuyucu
+// Copyright 2025 Laurynas Četyrkinas
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	verdictConfirmed    = "confirmed"
+	verdictDenied       = "denied"
+	verdictInconclusive = "inconclusive"
+)
+
+// permissionProbe is a read-only request that only succeeds with the
+// permission. With needsResults an empty list proves nothing.
+type permissionProbe struct {
+	path         string
+	needsResults bool
+}
+
+var permissionProbes = map[string][]permissionProbe{
+	"BROWSE_PROJECTS": {
+		{path: "/rest/api/3/project/search?action=browse&maxResults=1", needsResults: true},
+		{path: "/rest/api/3/search/jql?jql=order%20by%20created%20DESC&maxResults=1&fields=summary", needsResults: true},
+	},
+	"CREATE_ISSUES": {
+		{path: "/rest/api/3/project/search?action=create&maxResults=1", needsResults: true},
+	},
+	"ADMINISTER_PROJECTS": {
+		{path: "/rest/api/3/project/search?action=edit&maxResults=1", needsResults: true},
+	},
+	"USER_PICKER": {
+		{path: "/rest/api/3/user/picker?query=a&maxResults=1", needsResults: true},
+	},
+	"BROWSE_USERS": {
+		{path: "/rest/api/3/user/picker?query=a&maxResults=1", needsResults: true},
+	},
+	"ADMINISTER": {
+		{path: "/rest/api/3/applicationrole"},
+	},
+	"SYSTEM_ADMIN": {
+		{path: "/rest/api/3/auditing/record?limit=1"},
+	},
+}
+
+// PermissionVerification records whether a granted permission was proven
+// by a probe, together with the requests that decided it
+type PermissionVerification struct {
+	Key      string
+	Name     string
+	Verdict  string
+	Evidence string
+}
+
+// verifyPermissions runs the probes of every granted permission. The first
+// probe that succeeds confirms it; a refusal without any success denies it.
+func verifyPermissions(client *Client, perms map[string]MyPermission) []PermissionVerification {
+	keys := make([]string, 0, len(perms))
+	for key, perm := range perms {
+		if perm.HavePermission {
+			keys = append(keys, key)
+		}
+	}
+	sortPermissionKeys(keys, func(string) bool { return true }, func(key string) MyPermission { return perms[key] })
+
+	fmt.Printf("\nVerifying %d granted permission(s)\n", len(keys))
+
+	verifications := make([]PermissionVerification, 0, len(keys))
+	for _, key := range keys {
+		v := PermissionVerification{Key: key, Name: perms[key].Name, Verdict: verdictInconclusive}
+
+		probes := permissionProbes[key]
+		if len(probes) == 0 {
+			v.Evidence = "no read-only probe"
+		}
+
+		var evidence []string
+		for _, probe := range probes {
+			verdict, result := runProbe(client, probe)
+			evidence = append(evidence, result)
+
+			if verdict == verdictConfirmed {
+				v.Verdict = verdictConfirmed
+				evidence = []string{result}
+				break
+			}
+			if verdict == verdictDenied {
+				v.Verdict = verdictDenied
+			}
+		}
+		if len(evidence) > 0 {
+			v.Evidence = strings.Join(evidence, "; ")
+		}
+
+		verifications = append(verifications, v)
+	}
+
+	return verifications
+}
+
+func runProbe(client *Client, probe permissionProbe) (string, string) {
+	request := "GET " + probe.path
+
+	resp, err := client.get(probe.path)
+	if err != nil {
+		return verdictInconclusive, fmt.Sprintf("%s -> %v", request, err)
+	}
+
+	body, err := readBody(resp)
+	if err != nil {
+		return verdictInconclusive, fmt.Sprintf("%s -> %d, %v", request, resp.StatusCode, err)
+	}
+
+	switch {
+	case resp.StatusCode == 401 || resp.StatusCode == 403:
+		return verdictDenied, fmt.Sprintf("%s -> %d", request, resp.StatusCode)
+	case resp.StatusCode != 200:
+		return verdictInconclusive, fmt.Sprintf("%s -> %d", request, resp.StatusCode)
+	}
+
+	items := countItems(body)
+	if items < 0 {
+		return verdictConfirmed, fmt.Sprintf("%s -> 200", request)
+	}
+
+	result := fmt.Sprintf("%s -> 200, %d item(s)", request, items)
+	if items == 0 && probe.needsResults {
+		return verdictInconclusive, result
+	}
+	return verdictConfirmed, result
+}
+
+// countItems returns the length of a JSON list response, or of the list
+// wrapped in a page object, and -1 for anything else
+func countItems(body []byte) int {
+	var list []json.RawMessage
+	if json.Unmarshal(body, &list) == nil {
+		return len(list)
+	}
+
+	var page map[string]json.RawMessage
+	if json.Unmarshal(body, &page) != nil {
+		return -1
+	}
+
+	for _, field := range []string{"values", "issues", "users", "records"} {
+		if raw, ok := page[field]; ok && json.Unmarshal(raw, &list) == nil {
+			return len(list)
+		}
+	}
+	return -1
+}
+
+func writeVerificationsToCSV(verifications []PermissionVerification, outputPath string) error {
+	file, err := os.Create(outputPath)
+	if err != nil {
+		return fmt.Errorf("create CSV file: %w", err)
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	defer writer.Flush()
+
+	if err := writer.Write([]string{"Permission", "Name", "Verdict", "Evidence"}); err != nil {
+		return fmt.Errorf("write CSV header: %w", err)
+	}
+
+	for _, v := range verifications {
+		if err := writer.Write([]string{v.Key, v.Name, v.Verdict, v.Evidence}); err != nil {
+			return fmt.Errorf("write CSV row: %w", err)
+		}
+	}
+
+	fmt.Printf("\nWrote %d verifications to %s\n", len(verifications), outputPath)
+	return nil
+}
+
+func printVerifications(verifications []PermissionVerification) {
+	fmt.Println("\nVerification:")
+	fmt.Println(strings.Repeat("-", 80))
+
+	for _, v := range verifications {
+		fmt.Printf("[%-12s] %s\n", v.Verdict, v.Key)
+		fmt.Println("  Evidence: " + v.Evidence)
+	}
+}